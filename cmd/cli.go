@@ -2,10 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"regexp"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/hadisinaee/pz/input"
+	"github.com/hadisinaee/pz/prettierzap"
+	"github.com/hadisinaee/pz/query"
 	"github.com/urfave/cli"
 )
 
@@ -16,52 +21,487 @@ type CLIConfig struct {
 	Version string // app version
 }
 
+// Options represents the values given to the cli flags
+type Options struct {
+	Level         string                 // just logs with these comma separated levels
+	MinLevel      string                 // just logs at least as severe as this level
+	MaxLevel      string                 // just logs at most as severe as this level
+	Timestamp     string                 // just logs after this timestamp
+	Since         time.Time              // just logs at or after this time
+	Until         time.Time              // just logs before this time
+	Caller        string                 // just logs that their caller contains this
+	Logger        []string               // just logs of loggers matching these glob patterns
+	KeyValuePairs map[string]*string     // just logs that have these key-value pairs
+	Keys          prettierzap.KeyMapping // keys of the core fields given by the config file and the flags
+	DetectKeys    bool                   // detect the keys of the core fields that aren't given
+	TimeEncoding  string                 // encoding of the timestamps
+	TimeFormat    string                 // layout or preset of the printed timestamps
+	Location      *time.Location         // time zone of the printed timestamps and of the given times
+	Emoji         bool                   // add some funny emoji to output
+	Theme         *prettierzap.Theme     // styles of the pretty output
+	Template      string                 // Go text/template of the pretty output, the built-in layout if empty
+	MetaOrder     string                 // order of the meta fields, one of the prettierzap MetaOrder constants
+	PinnedKeys    []string               // meta fields written first
+	Fields        []string               // patterns of the meta fields shown, all of them if empty
+	Hide          []string               // patterns of the meta fields hidden
+	Output        string                 // format of the output, one of the prettierzap Output constants
+	Columns       []string               // fields written by the csv and tsv outputs
+	MaxDepth      int                    // maximum depth of nested fields to expand
+	MaxArrayItems int                    // maximum number of array items to show
+	ExpandDumps   bool                   // show the stacks of all goroutines of panics and dumps
+	Module        string                 // import path of the application, highlighted in stacktraces
+	StackFrames   int                    // number of frames of the application to show of stacktraces, 0 means all
+	Query         *query.Query           // just logs that match this query expression
+	Format        string                 // name of the input format
+	Parser        prettierzap.Parser     // parser of the input format
+	Inputs        []string               // files to read, `-` for the standard input
+	Follow        bool                   // follow the files as they grow, through rotations
+	Lines         int                    // number of last lines of the followed files to start with
+	Workers       int                    // goroutines parsing, filtering and rendering the logs
+	MaxLineSize   int                    // lines are truncated to this many bytes, 0 means no limit
+	Multiline     bool                   // group continuation lines into the records they belong to
+	RecordStart   *regexp.Regexp         // lines matching it start records, if set
+}
+
 var app *cli.App
 
-// InitCLI initialize the cli with the given config object
-func InitCLI(cfg CLIConfig, level, timestamp, caller *string, keyValuePairs map[string]*string, emoji *bool) error {
+// InitCLI initialize the cli with the given config object.
+// the values of the flags are stored in the given options object when the cli runs.
+func InitCLI(cfg CLIConfig, opts *Options) error {
+	if opts.KeyValuePairs == nil {
+		opts.KeyValuePairs = make(map[string]*string, 0)
+	}
+
 	app = cli.NewApp()
 	app.Name = cfg.Name
 	app.Usage = cfg.Usage
 	app.Version = cfg.Version
+	app.ArgsUsage = "[file|directory|glob ...]"
 
-	var tempKVs string
+	var (
+		tempKVs        string
+		tempQuery      string
+		tempLevelNames string
+		tempConfig     string
+		tempKeys       string
+		tempLevelKey   string
+		tempTimeKeys   string
+		tempCallerKey  string
+		tempMsgKey     string
+		tempNameKey    string
+		tempStackKey   string
+		tempSince      string
+		tempUntil      string
+		tempTZ         string
+		tempLogger     string
+		tempFormat     string
+		tempStart      string
+		tempMaxLine    string
+		tempOutput     string
+		tempColumns    string
+		tempColor      string
+		tempTheme      string
+		tempTemplate   string
+		tempMetaOrder  string
+		tempPinKeys    string
+		tempFields     string
+		tempHide       string
+	)
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:        "l, level",
-			Usage:       "just logs with log level of `log_level`",
-			Destination: level,
+			Usage:       "just logs with log level of `log_level`, or any of a comma separated list of levels like error,fatal",
+			Destination: &opts.Level,
+		},
+		cli.StringFlag{
+			Name:        "min-level",
+			Usage:       "just logs with a level at least as severe as `log_level`",
+			Destination: &opts.MinLevel,
+		},
+		cli.StringFlag{
+			Name:        "max-level",
+			Usage:       "just logs with a level at most as severe as `log_level`",
+			Destination: &opts.MaxLevel,
+		},
+		cli.StringFlag{
+			Name:        "level-names",
+			Usage:       "custom level names of non-standard encoders as `name=level` pairs, where level is a zap level or a severity number, e.g. notice=info,crit=fatal,trace=-2",
+			Destination: &tempLevelNames,
 		},
 		cli.StringFlag{
 			Name:        "t, timestamp",
-			Usage:       "just logs after the `timestamp`(>=). it is possible to use the following keywords with `timestamp`:\n\t\t\tnow: to show all logs from the current time\n\t\t\ttoday: to show all logs of the tody(start from 00:00)",
-			Destination: timestamp,
+			Usage:       "just logs after the `timestamp`(>=). it is possible to use the following keywords with `timestamp`:\n\t\t\tnow: to show all logs from the current time\n\t\t\ttoday: to show all logs of the tody(start from 00:00)\n\t\t\tit accepts the same times as --since",
+			Destination: &opts.Timestamp,
+		},
+		cli.StringFlag{
+			Name:        "since",
+			Usage:       "just logs at or after `time`: now, today, yesterday, a time of day(14:30), a duration ago(15m, 2h ago, 3d), a date(2018-03-30), an RFC3339 time or an epoch timestamp",
+			Destination: &tempSince,
+		},
+		cli.StringFlag{
+			Name:        "until",
+			Usage:       "just logs before `time`, it accepts the same times as --since",
+			Destination: &tempUntil,
+		},
+		cli.StringFlag{
+			Name:        "time-encoding",
+			Usage:       "`encoding` of the timestamps: auto, epoch, epoch-millis, epoch-micros, epoch-nanos, iso8601, rfc3339 or a Go time layout",
+			Value:       string(prettierzap.TimeEncodingAuto),
+			Destination: &opts.TimeEncoding,
 		},
 		cli.StringFlag{
 			Name:        "c, caller",
 			Usage:       "just logs that its caller field contains `caller_name`",
-			Destination: caller,
+			Destination: &opts.Caller,
+		},
+		cli.StringFlag{
+			Name:        "logger",
+			Usage:       "just logs of the loggers matching any of the comma separated glob `patterns`, e.g. 'api.*,db'",
+			Destination: &tempLogger,
 		},
 		cli.StringFlag{
 			Name:        "k, keyvalue",
-			Usage:       "just logs that have specific pairs of `key_1=value_1`",
+			Usage:       "just logs that have specific pairs of `key_1=value_1`, keys can be paths into nested fields like http.status or tags[0]",
 			Destination: &tempKVs,
 		},
+		cli.StringFlag{
+			Name:        "q, query",
+			Usage:       "just logs that match the `expression`, e.g. 'level in (error, warn) && http.status >= 500 || msg contains \"timeout\"'",
+			Destination: &tempQuery,
+		},
+		cli.StringFlag{
+			Name:        "time-format",
+			Usage:       "print timestamps with the `format`: a Go time layout or one of default, rfc3339, rfc3339nano, iso8601, kitchen, stamp, stampmilli, stampmicro, datetime, millis, relative, delta-since-previous",
+			Value:       "default",
+			Destination: &opts.TimeFormat,
+		},
+		cli.StringFlag{
+			Name:        "tz",
+			Usage:       "print timestamps, and read times given without a zone, in the time `zone`: UTC, Local or an IANA name like Asia/Tehran",
+			Value:       "Local",
+			Destination: &tempTZ,
+		},
+		cli.StringFlag{
+			Name:        "o, output",
+			Usage:       "write the logs in the `format`: pretty, json(the original lines), ndjson-normalized(zap's keys and decoded timestamps), logfmt, csv or tsv",
+			Value:       prettierzap.OutputPretty,
+			Destination: &tempOutput,
+		},
+		cli.StringFlag{
+			Name:        "columns",
+			Usage:       "write the comma separated `fields` as the columns of the csv and tsv outputs, e.g. ts,level,msg,http.status",
+			Value:       strings.Join(prettierzap.DefaultColumns, ","),
+			Destination: &tempColumns,
+		},
+		cli.StringFlag{
+			Name:        "format",
+			Usage:       "`format` of the input: " + strings.Join(prettierzap.ParserNames(), ", ") + ", auto detects the format of each line",
+			Value:       prettierzap.FormatAuto,
+			Destination: &tempFormat,
+		},
+		cli.BoolFlag{
+			Name:        "f, follow",
+			Usage:       "follow the files as they grow, like tail -F, reopening them when they're rotated or truncated",
+			Destination: &opts.Follow,
+		},
+		cli.IntFlag{
+			Name:        "n, lines",
+			Usage:       "start following the files with their last `n` lines",
+			Value:       10,
+			Destination: &opts.Lines,
+		},
+		cli.IntFlag{
+			Name:        "workers",
+			Usage:       "parse, filter and render the logs on `n` goroutines, the output keeps the order of the input(1 for no concurrency)",
+			Value:       runtime.NumCPU(),
+			Destination: &opts.Workers,
+		},
+		cli.StringFlag{
+			Name:        "max-line-size",
+			Usage:       "truncate lines longer than `size`, like 512k or 4MiB, and mark them as truncated(0 for no limit)",
+			Value:       "1MiB",
+			Destination: &tempMaxLine,
+		},
+		cli.BoolTFlag{
+			Name:        "multiline",
+			Usage:       "group lines that aren't logs, like stacktraces and panics, into the records they belong to, use --multiline=false to turn it off",
+			Destination: &opts.Multiline,
+		},
+		cli.BoolFlag{
+			Name:        "expand-goroutines",
+			Usage:       "show the stacks of all goroutines of panics and goroutine dumps, not just of the one that panicked",
+			Destination: &opts.ExpandDumps,
+		},
+		cli.StringFlag{
+			Name:        "module",
+			Usage:       "highlight the stacktrace frames of the Go module with the import `path`, other frames are dimmed (default: the module of ./go.mod)",
+			Destination: &opts.Module,
+		},
+		cli.IntFlag{
+			Name:        "stack-frames",
+			Usage:       "show just the top `n` frames of the application of stacktraces(0 for all frames)",
+			Destination: &opts.StackFrames,
+		},
+		cli.StringFlag{
+			Name:        "record-start",
+			Usage:       "lines matching the `regex` start records and the other lines continue them, by default lines of the input format start records",
+			Destination: &tempStart,
+		},
+		cli.StringFlag{
+			Name:        "config",
+			Usage:       "read the keys of the fields from the JSON config `file`, with the keys of zap's EncoderConfig like levelKey and timeKey",
+			Destination: &tempConfig,
+		},
+		cli.StringFlag{
+			Name:        "keys",
+			Usage:       "read the fields from the keys given as `field=key` pairs, e.g. level=severity,time=time,caller=source",
+			Destination: &tempKeys,
+		},
+		cli.StringFlag{
+			Name:        "level-key",
+			Usage:       "read levels from the first of the comma separated `keys` the log has (default: level)",
+			Destination: &tempLevelKey,
+		},
+		cli.StringFlag{
+			Name:        "time-key",
+			Usage:       "read timestamps from the first of the comma separated `keys` the log has (default: ts)",
+			Destination: &tempTimeKeys,
+		},
+		cli.StringFlag{
+			Name:        "caller-key",
+			Usage:       "read callers from the first of the comma separated `keys` the log has (default: caller)",
+			Destination: &tempCallerKey,
+		},
+		cli.StringFlag{
+			Name:        "message-key",
+			Usage:       "read messages from the first of the comma separated `keys` the log has (default: msg,message)",
+			Destination: &tempMsgKey,
+		},
+		cli.StringFlag{
+			Name:        "name-key",
+			Usage:       "read logger names from the first of the comma separated `keys` the log has (default: logger)",
+			Destination: &tempNameKey,
+		},
+		cli.StringFlag{
+			Name:        "stacktrace-key",
+			Usage:       "read stacktraces from the first of the comma separated `keys` the log has (default: stacktrace)",
+			Destination: &tempStackKey,
+		},
+		cli.BoolTFlag{
+			Name:        "detect-keys",
+			Usage:       "detect the keys of the fields that aren't given from the first lines, use --detect-keys=false to turn it off",
+			Destination: &opts.DetectKeys,
+		},
 		cli.BoolFlag{
 			Name:        "e, emoji",
 			Usage:       "add some funny emoji to output",
-			Destination: emoji,
+			Destination: &opts.Emoji,
+		},
+		cli.StringFlag{
+			Name:        "color",
+			Usage:       "colorize the output in the `mode`: auto(on terminals, unless NO_COLOR is set), always or never",
+			Value:       prettierzap.ColorAuto,
+			Destination: &tempColor,
+		},
+		cli.StringFlag{
+			Name:        "theme",
+			Usage:       "style the output with the `theme`: " + strings.Join(prettierzap.ThemeNames(), ", ") + ", or a JSON theme file",
+			Value:       prettierzap.ThemeDark,
+			Destination: &tempTheme,
+		},
+		cli.StringFlag{
+			Name:        "template",
+			Usage:       "write the logs with the `template`: " + strings.Join(append([]string{"default"}, prettierzap.TemplateNames()...), ", ") + ", or a Go text/template like '{{.Time}} {{.Level | upper}} {{.Msg}} {{field \"http.status\"}}'",
+			Destination: &tempTemplate,
+		},
+		cli.StringFlag{
+			Name:        "meta-order",
+			Usage:       "write the meta fields in the `order`: original(the order of the lines) or sorted",
+			Value:       prettierzap.MetaOrderOriginal,
+			Destination: &tempMetaOrder,
+		},
+		cli.StringFlag{
+			Name:        "pin-keys",
+			Usage:       "write the meta fields with the comma separated `keys` first, e.g. request_id,user_id",
+			Destination: &tempPinKeys,
+		},
+		cli.StringFlag{
+			Name:        "fields",
+			Usage:       "show just the meta fields matching the comma separated `patterns`: dotted paths with globs, e.g. user_id,http.*",
+			Destination: &tempFields,
+		},
+		cli.StringFlag{
+			Name:        "hide",
+			Usage:       "hide the meta fields matching the comma separated `patterns`, e.g. hostname,pid,build.*",
+			Destination: &tempHide,
+		},
+		cli.IntFlag{
+			Name:        "depth",
+			Usage:       "expand nested fields up to `depth` levels, deeper fields are collapsed into one line(0 for no limit)",
+			Value:       prettierzap.DefaultMaxDepth,
+			Destination: &opts.MaxDepth,
+		},
+		cli.IntFlag{
+			Name:        "max-items",
+			Usage:       "show at most `n` items of an array field(0 for no limit)",
+			Value:       prettierzap.DefaultMaxArrayItems,
+			Destination: &opts.MaxArrayItems,
 		},
 	}
 
 	app.Action = func(c *cli.Context) error {
-		if strings.Compare(*timestamp, "now") == 0 {
-			*timestamp = fmt.Sprintf("%v", time.Now().Unix())
-		} else if strings.Compare(*timestamp, "today") == 0 {
-			y, m, d := time.Now().Date()
-			l, _ := time.LoadLocation("Local")
-			t := time.Date(y, m, d, 0, 0, 0, 0, l)
-			*timestamp = fmt.Sprintf("%v", t.Truncate(24*time.Hour).Unix())
+		inputs, errInputs := input.Expand(c.Args())
+		if errInputs != nil {
+			return cli.NewExitError(errInputs.Error(), 1)
+		}
+		if len(inputs) == 0 {
+			inputs = []string{input.Stdin}
+		}
+		opts.Inputs = inputs
+
+		loc, errLoc := prettierzap.ParseLocation(tempTZ)
+		if errLoc != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid --tz: %v", errLoc), 1)
+		}
+		opts.Location = loc
+		opts.TimeFormat = prettierzap.ParseTimeFormat(opts.TimeFormat)
+
+		now := time.Now()
+		if opts.Timestamp != "" && tempSince == "" {
+			tempSince = opts.Timestamp
+		}
+		if tempSince != "" {
+			t, errTime := prettierzap.ParseTimeSpec(tempSince, now, loc)
+			if errTime != nil {
+				return cli.NewExitError(fmt.Sprintf("invalid --since: %v", errTime), 1)
+			}
+			opts.Since = t
+		}
+		if tempUntil != "" {
+			t, errTime := prettierzap.ParseTimeSpec(tempUntil, now, loc)
+			if errTime != nil {
+				return cli.NewExitError(fmt.Sprintf("invalid --until: %v", errTime), 1)
+			}
+			opts.Until = t
+		}
+
+		if tempConfig != "" {
+			cfg, errConfig := LoadConfig(tempConfig)
+			if errConfig != nil {
+				return cli.NewExitError(fmt.Sprintf("invalid --config: %v", errConfig), 1)
+			}
+			opts.Keys = cfg.KeyMapping()
+			if tempTemplate == "" {
+				tempTemplate = cfg.Template
+			}
+			fp := cfg.Projection(tempTemplate)
+			opts.Fields, opts.Hide = fp.Fields, fp.Hide
+		}
+		opts.Template = prettierzap.ParseTemplate(tempTemplate)
+		if tempKeys != "" {
+			keys, errKeys := prettierzap.ParseKeyMapping(tempKeys)
+			if errKeys != nil {
+				return cli.NewExitError(fmt.Sprintf("invalid --keys: %v", errKeys), 1)
+			}
+			opts.Keys = opts.Keys.Override(keys)
+		}
+		opts.Keys = opts.Keys.Override(prettierzap.KeyMapping{
+			Level:      splitList(tempLevelKey),
+			Time:       splitList(tempTimeKeys),
+			Caller:     splitList(tempCallerKey),
+			Message:    splitList(tempMsgKey),
+			Name:       splitList(tempNameKey),
+			Stacktrace: splitList(tempStackKey),
+		})
+		prettierzap.SetTimeEncoding(prettierzap.ParseTimeEncoding(opts.TimeEncoding))
+
+		if errLevels := prettierzap.ParseLevelNames(tempLevelNames); errLevels != nil {
+			return cli.NewExitError(errLevels.Error(), 1)
+		}
+		for _, l := range []string{opts.MinLevel, opts.MaxLevel} {
+			if _, ok := prettierzap.ParseSeverity(l); l != "" && !ok {
+				return cli.NewExitError((&prettierzap.LevelError{Level: l}).Error(), 1)
+			}
+		}
+
+		parser, errFormat := prettierzap.LookupParser(tempFormat)
+		if errFormat != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid --format: %v", errFormat), 1)
+		}
+		output, errOutput := prettierzap.ParseOutput(tempOutput)
+		if errOutput != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid --output: %v", errOutput), 1)
+		}
+		opts.Output = output
+
+		if errColor := prettierzap.SetColorMode(tempColor); errColor != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid --color: %v", errColor), 1)
+		}
+		theme, errTheme := loadTheme(tempTheme)
+		if errTheme != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid --theme: %v", errTheme), 1)
+		}
+		opts.Theme = theme
+		opts.Columns = prettierzap.ParseColumns(tempColumns)
+
+		metaOrder, errOrder := prettierzap.ParseMetaOrder(tempMetaOrder)
+		if errOrder != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid --meta-order: %v", errOrder), 1)
+		}
+		opts.MetaOrder = metaOrder
+		opts.PinnedKeys = splitList(tempPinKeys)
+
+		if tempFields != "" {
+			fields, errFields := prettierzap.ParseFieldPatterns(tempFields)
+			if errFields != nil {
+				return cli.NewExitError(fmt.Sprintf("invalid --fields: %v", errFields), 1)
+			}
+			opts.Fields = fields
+		}
+		if tempHide != "" {
+			hide, errHide := prettierzap.ParseFieldPatterns(tempHide)
+			if errHide != nil {
+				return cli.NewExitError(fmt.Sprintf("invalid --hide: %v", errHide), 1)
+			}
+			opts.Hide = hide
+		}
+
+		if opts.Module == "" {
+			opts.Module = modulePath("go.mod")
+		}
+
+		opts.Format = strings.ToLower(strings.TrimSpace(tempFormat))
+		opts.Parser = parser
+
+		maxLine, errSize := input.ParseSize(tempMaxLine)
+		if errSize != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid --max-line-size: %v", errSize), 1)
+		}
+		opts.MaxLineSize = maxLine
+
+		if tempStart != "" {
+			re, errStart := regexp.Compile(tempStart)
+			if errStart != nil {
+				return cli.NewExitError(fmt.Sprintf("invalid --record-start: %v", errStart), 1)
+			}
+			opts.RecordStart = re
+		}
+
+		loggers, errLogger := prettierzap.ParseLoggerPatterns(tempLogger)
+		if errLogger != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid --logger: %v", errLogger), 1)
+		}
+		opts.Logger = loggers
+
+		if tempQuery != "" {
+			q, errParse := query.Parse(tempQuery)
+			if errParse != nil {
+				if se, ok := errParse.(*query.SyntaxError); ok {
+					return cli.NewExitError(fmt.Sprintf("%v\n%s", se, se.Pointer()), 1)
+				}
+				return cli.NewExitError(errParse.Error(), 1)
+			}
+			opts.Query = q
 		}
 
 		pairs := strings.Split(tempKVs, ",")
@@ -74,20 +514,28 @@ func InitCLI(cfg CLIConfig, level, timestamp, caller *string, keyValuePairs map[
 			_, errParse := strconv.ParseFloat(kv[1], 64)
 			if errParse != nil {
 				kv[1] = fmt.Sprintf("\"%s\"", kv[1])
-				keyValuePairs[kv[0]] = &kv[1]
+				opts.KeyValuePairs[kv[0]] = &kv[1]
 			} else {
-				keyValuePairs[kv[0]] = &kv[1]
+				opts.KeyValuePairs[kv[0]] = &kv[1]
 			}
 
 		}
 
-		title := fmt.Sprintf("\n[PRITTIER ZAP] Level: '%v' Timestamp: '%v' Caller: '%v' Emoji: '%v'", *level, *timestamp, *caller, *emoji)
-		if len(keyValuePairs) > 0 {
+		// the output of the other formats is read by other tools, and templates lay out all of theirs
+		if opts.Output != prettierzap.OutputPretty || opts.Template != "" {
+			return nil
+		}
+
+		title := fmt.Sprintf("\n[PRITTIER ZAP] Level: '%v' Min Level: '%v' Max Level: '%v' Since: '%v' Until: '%v' Caller: '%v' Logger: '%v' Emoji: '%v'", opts.Level, opts.MinLevel, opts.MaxLevel, formatTime(opts.Since), formatTime(opts.Until), opts.Caller, strings.Join(opts.Logger, ","), opts.Emoji)
+		if len(opts.KeyValuePairs) > 0 {
 			title += " Key-Value:"
 		}
-		for k, v := range keyValuePairs {
+		for k, v := range opts.KeyValuePairs {
 			title += fmt.Sprintf(" %s:%s", k, *v)
 		}
+		if opts.Query != nil {
+			title += fmt.Sprintf(" Query: '%v'", opts.Query)
+		}
 		title += "\n"
 		fmt.Println(title)
 		return nil
@@ -95,6 +543,14 @@ func InitCLI(cfg CLIConfig, level, timestamp, caller *string, keyValuePairs map[
 	return nil
 }
 
+// formatTime returns the given time for the title, or nothing if it isn't set.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339Nano)
+}
+
 // Run runs the cli application with given os arguments.
 func Run(osArgs []string) error {
 	errRun := app.Run(osArgs)
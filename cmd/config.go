@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/hadisinaee/pz/prettierzap"
+)
+
+// EncoderKeys represents the keys of zap's EncoderConfig, with the same JSON names,
+// so the encoder config of a service can be used as it is.
+type EncoderKeys struct {
+	MessageKey    string `json:"messageKey"`
+	LevelKey      string `json:"levelKey"`
+	TimeKey       string `json:"timeKey"`
+	NameKey       string `json:"nameKey"`
+	CallerKey     string `json:"callerKey"`
+	StacktraceKey string `json:"stacktraceKey"`
+}
+
+// Config represents the config file of the cli.
+// the encoder keys can be given at the top level or under `encoderConfig`, like in a zap.Config.
+type Config struct {
+	EncoderKeys
+	FieldProjection
+	EncoderConfig *EncoderKeys               `json:"encoderConfig"`
+	Template      string                     `json:"template"` // template of the pretty output, like the --template flag
+	Presets       map[string]FieldProjection `json:"presets"`  // fields shown with the template presets, by their names
+}
+
+// FieldProjection represents the meta fields shown, like the --fields and --hide flags.
+type FieldProjection struct {
+	Fields []string `json:"fields"`
+	Hide   []string `json:"hide"`
+}
+
+// Projection returns the meta fields shown with the template with the given name, `default` for the
+// built-in layout: the ones set for its preset, or the ones set at the top level.
+func (c *Config) Projection(template string) FieldProjection {
+	fp := c.FieldProjection
+	name := strings.ToLower(strings.TrimSpace(template))
+	if name == "" {
+		name = "default"
+	}
+	if preset, ok := c.Presets[name]; ok {
+		if len(preset.Fields) > 0 {
+			fp.Fields = preset.Fields
+		}
+		if len(preset.Hide) > 0 {
+			fp.Hide = preset.Hide
+		}
+	}
+	return fp
+}
+
+// LoadConfig reads the config file with the given path.
+func LoadConfig(path string) (*Config, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var c Config
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// KeyMapping returns the key mapping set in the config.
+func (c *Config) KeyMapping() prettierzap.KeyMapping {
+	m := c.EncoderKeys.keyMapping()
+	if c.EncoderConfig != nil {
+		m = m.Override(c.EncoderConfig.keyMapping())
+	}
+	return m
+}
+
+// keyMapping returns the key mapping of the encoder keys that are set.
+func (k EncoderKeys) keyMapping() prettierzap.KeyMapping {
+	return prettierzap.KeyMapping{
+		Level:      splitList(k.LevelKey),
+		Time:       splitList(k.TimeKey),
+		Caller:     splitList(k.CallerKey),
+		Message:    splitList(k.MessageKey),
+		Name:       splitList(k.NameKey),
+		Stacktrace: splitList(k.StacktraceKey),
+	}
+}
+
+// splitList splits a comma separated list, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// modulePath returns the import path of the module of the go.mod file with the given path,
+// or nothing if it can't be read.
+func modulePath(path string) string {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(b), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 2 && fields[0] == "module" {
+			return strings.Trim(fields[1], `"`)
+		}
+	}
+	return ""
+}
+
+// ThemeFile represents a theme file: styles of roles like `level.error` over the styles of a built-in theme.
+type ThemeFile struct {
+	Base   string            `json:"base"` // name of the built-in theme, dark if empty
+	Styles map[string]string `json:"styles"`
+}
+
+// loadTheme returns the built-in theme with the given name, or the theme of the theme file with the given path.
+func loadTheme(name string) (*prettierzap.Theme, error) {
+	depth := prettierzap.DetectColorDepth()
+	if styles, ok := prettierzap.ThemeStyles(name); ok {
+		return prettierzap.NewTheme(styles, depth)
+	}
+
+	b, err := ioutil.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("%q is neither a theme(%s) nor a theme file: %v", name, strings.Join(prettierzap.ThemeNames(), ", "), err)
+	}
+	var f ThemeFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, err
+	}
+	if f.Base == "" {
+		f.Base = prettierzap.ThemeDark
+	}
+	styles, ok := prettierzap.ThemeStyles(f.Base)
+	if !ok {
+		return nil, fmt.Errorf("unknown base theme %q", f.Base)
+	}
+	for role, spec := range f.Styles {
+		styles[role] = spec
+	}
+	return prettierzap.NewTheme(styles, depth)
+}
@@ -0,0 +1,171 @@
+package input
+
+import (
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is how often a followed file is checked for new lines.
+const DefaultPollInterval = 250 * time.Millisecond
+
+// ErrIdle is returned by the reader of a followed file when it reached the end of the file
+// and waits for new lines. it is temporary, reading again waits for the next lines.
+var ErrIdle error = idleError{}
+
+type idleError struct{}
+
+func (idleError) Error() string   { return "waiting for new lines" }
+func (idleError) Temporary() bool { return true }
+
+// Follower reads a file as it grows, like `tail -F`.
+// when the file is truncated it reads it again from the start, and when it is rotated by
+// renaming it, as logrotate and lumberjack do, it reads the rest of the old file and goes on
+// with the new file of the same name.
+type Follower struct {
+	Poll time.Duration // interval of the checks for new lines
+
+	path   string
+	file   *os.File
+	offset int64
+	idle   bool
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+// Follow opens the file to follow it, starting with its last n lines.
+func Follow(path string, n int) (*Follower, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	offset, err := tailOffset(f, n)
+	if err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &Follower{
+		Poll:   DefaultPollInterval,
+		path:   path,
+		file:   f,
+		offset: offset,
+		closed: make(chan struct{}),
+	}, nil
+}
+
+// Read reads the next bytes of the file, waiting for them at the end of the file.
+// it returns ErrIdle once each time it starts waiting, and io.EOF once the follower is closed.
+func (f *Follower) Read(p []byte) (int, error) {
+	for {
+		n, err := f.file.Read(p)
+		f.offset += int64(n)
+		if n > 0 {
+			f.idle = false
+			return n, nil
+		}
+		if err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		if reopened, err := f.checkRotation(); err != nil {
+			return 0, err
+		} else if reopened {
+			continue
+		}
+
+		if !f.idle {
+			f.idle = true
+			return 0, ErrIdle
+		}
+
+		select {
+		case <-f.closed:
+			return 0, io.EOF
+		case <-time.After(f.Poll):
+		}
+	}
+}
+
+// checkRotation reopens the file if it was rotated or truncated, once the old file is read to its end.
+func (f *Follower) checkRotation() (bool, error) {
+	current, err := f.file.Stat()
+	if err != nil {
+		return false, err
+	}
+
+	latest, err := os.Stat(f.path)
+	if err != nil {
+		// rotated away and not created again yet
+		return false, nil
+	}
+
+	if !os.SameFile(current, latest) {
+		nf, err := os.Open(f.path)
+		if err != nil {
+			return false, nil
+		}
+		f.file.Close()
+		f.file, f.offset = nf, 0
+		return true, nil
+	}
+
+	if latest.Size() < f.offset {
+		if _, err := f.file.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		f.offset = 0
+		return true, nil
+	}
+	return false, nil
+}
+
+// Close stops following the file.
+func (f *Follower) Close() error {
+	f.closeOnce.Do(func() { close(f.closed) })
+	return f.file.Close()
+}
+
+// tailOffset returns the offset of the last n lines of the file.
+func tailOffset(f *os.File, n int) (int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	size := fi.Size()
+	if n <= 0 {
+		return size, nil
+	}
+
+	const chunk = 32 * 1024
+	buf := make([]byte, chunk)
+	end := size
+	for end > 0 {
+		start := end - chunk
+		if start < 0 {
+			start = 0
+		}
+		b := buf[:end-start]
+		if _, err := f.ReadAt(b, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+
+		for i := len(b) - 1; i >= 0; i-- {
+			// the newline ending the file doesn't start a line
+			if b[i] != '\n' || start+int64(i) == size-1 {
+				continue
+			}
+			if n--; n == 0 {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
@@ -0,0 +1,110 @@
+package input
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// readUntilIdle returns the lines read from the follower until it waits for new lines.
+func readUntilIdle(t *testing.T, lr *LineReader) []string {
+	var lines []string
+	for {
+		l, _, err := lr.ReadLine()
+		if err == ErrIdle {
+			return lines
+		}
+		if err != nil {
+			t.Fatalf("ReadLine: expected no error received: %v", err)
+		}
+		lines = append(lines, string(l))
+	}
+}
+
+func appendFile(t *testing.T, path, text string) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(text)
+	f.Close()
+}
+
+func TestFollow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	appendFile(t, path, "1\n2\n3\n")
+
+	f, err := Follow(path, 2)
+	if err != nil {
+		t.Fatalf("Follow: expected no error received: %v", err)
+	}
+	defer f.Close()
+	f.Poll = time.Millisecond
+	lr := NewLineReader(f, 0)
+
+	check := func(step string, want ...string) {
+		got := readUntilIdle(t, lr)
+		if len(got) != len(want) {
+			t.Fatalf("%s: expected %q received: %q", step, want, got)
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s: expected %q received: %q", step, want, got)
+			}
+		}
+	}
+
+	check("last lines", "2", "3")
+
+	appendFile(t, path, "4\n5")
+	check("appended", "4")
+	appendFile(t, path, "\n")
+	check("end of a partial line", "5")
+
+	// rotation by renaming, like lumberjack
+	appendFile(t, path, "6\n")
+	os.Rename(path, path+".1")
+	appendFile(t, path, "new 1\n")
+	check("rotated", "6", "new 1")
+
+	// truncation, like logrotate's copytruncate
+	os.Truncate(path, 0)
+	appendFile(t, path, "t\n")
+	check("truncated", "t")
+}
+
+func TestTailOffset(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, tc := range []struct {
+		Text string
+		N    int
+		Want int64
+	}{
+		{"a\nb\nc\n", 2, 2},
+		{"a\nb\nc", 2, 2},
+		{"a\nb\n", 5, 0},
+		{"a\nb\n", 0, 4},
+		{"", 3, 0},
+	} {
+		path := filepath.Join(dir, "f")
+		ioutil.WriteFile(path, []byte(tc.Text), 0644)
+		f, _ := os.Open(path)
+		off, err := tailOffset(f, tc.N)
+		f.Close()
+		if err != nil || off != tc.Want {
+			t.Errorf("tailOffset(%q, %d): expected %d received: %d (%v)", tc.Text, tc.N, tc.Want, off, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package input
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Stdin is the name of the standard input in the list of inputs.
+const Stdin = "-"
+
+// magic numbers of the compressed formats
+var (
+	gzipMagic  = []byte{0x1f, 0x8b}
+	bzip2Magic = []byte("BZh")
+	zstdMagic  = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// Expand returns the files of the given inputs: files, directories, whose files are taken
+// in the order of their names, and glob patterns like `logs/*.json`.
+// the standard input is given as `-`.
+func Expand(inputs []string) ([]string, error) {
+	var files []string
+	for _, in := range inputs {
+		if in == Stdin {
+			files = append(files, in)
+			continue
+		}
+
+		if strings.ContainsAny(in, "*?[") {
+			matches, err := filepath.Glob(in)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %v", in, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", in)
+			}
+			sort.Strings(matches)
+			files = append(files, matches...)
+			continue
+		}
+
+		fi, err := os.Stat(in)
+		if err != nil {
+			return nil, err
+		}
+		if !fi.IsDir() {
+			files = append(files, in)
+			continue
+		}
+
+		entries, err := ioutil.ReadDir(in)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			if e.Mode().IsRegular() && !strings.HasPrefix(e.Name(), ".") {
+				files = append(files, filepath.Join(in, e.Name()))
+			}
+		}
+	}
+	return files, nil
+}
+
+// Open opens the input with the given name, decompressing gzip, bzip2 and zstd files.
+// the compression is detected from the content of the file, not its name.
+// zstd files are decompressed by the zstd command, which has to be installed.
+func Open(name string) (io.ReadCloser, error) {
+	if name == Stdin {
+		return decompress(ioutil.NopCloser(os.Stdin), name)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return decompress(f, name)
+}
+
+// decompress returns the decompressed content of rc if it is compressed.
+func decompress(rc io.ReadCloser, name string) (io.ReadCloser, error) {
+	br := bufio.NewReader(rc)
+	magic, _ := br.Peek(4)
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("%s: %v", name, err)
+		}
+		return readCloser{zr, rc}, nil
+	case bytes.HasPrefix(magic, bzip2Magic):
+		return readCloser{bzip2.NewReader(br), rc}, nil
+	case bytes.HasPrefix(magic, zstdMagic):
+		z := &zstdReader{name: name, cmd: exec.Command("zstd", "-dcq"), in: rc}
+		z.cmd.Stdin = br
+		z.cmd.Stderr = &z.stderr
+		out, err := z.cmd.StdoutPipe()
+		if err == nil {
+			err = z.cmd.Start()
+		}
+		if err != nil {
+			rc.Close()
+			return nil, fmt.Errorf("%s: reading zstd files needs the zstd command: %v", name, err)
+		}
+		z.out = out
+		return z, nil
+	}
+	return readCloser{br, rc}, nil
+}
+
+// zstdReader reads the output of the zstd command, and fails with the error of the command
+// and its messages when it exits.
+type zstdReader struct {
+	name   string
+	cmd    *exec.Cmd
+	out    io.Reader
+	in     io.Closer
+	stderr bytes.Buffer
+	done   bool
+	err    error
+}
+
+func (z *zstdReader) Read(p []byte) (int, error) {
+	n, err := z.out.Read(p)
+	if err == io.EOF {
+		if werr := z.wait(); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// Close closes the input, stopping the command if its output wasn't read to the end.
+func (z *zstdReader) Close() error {
+	if !z.done {
+		z.cmd.Process.Kill()
+		z.cmd.Wait()
+		z.done = true
+	}
+	if err := z.in.Close(); z.err == nil {
+		return err
+	}
+	return z.err
+}
+
+// wait waits for the command to exit, and returns its error with its messages.
+func (z *zstdReader) wait() error {
+	if z.done {
+		return z.err
+	}
+	z.done = true
+	if err := z.cmd.Wait(); err != nil {
+		if msg := strings.TrimSpace(z.stderr.String()); msg != "" {
+			err = fmt.Errorf("%v: %s", err, msg)
+		}
+		z.err = fmt.Errorf("%s: zstd: %v", z.name, err)
+	}
+	return z.err
+}
+
+// readCloser reads from a reader and closes the underlying input.
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
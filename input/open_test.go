@@ -0,0 +1,105 @@
+package input
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpand(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"b.json", "a.json", "c.log", ".hidden"} {
+		ioutil.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+
+	testScenarios := []struct {
+		Name   string
+		Inputs []string
+		Wanted []string
+		Fails  bool
+	}{
+		{"pass - glob", []string{filepath.Join(dir, "*.json")}, []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")}, false},
+		{"pass - directory", []string{dir}, []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json"), filepath.Join(dir, "c.log")}, false},
+		{"pass - files and stdin", []string{filepath.Join(dir, "c.log"), "-"}, []string{filepath.Join(dir, "c.log"), "-"}, false},
+		{"fails - no match", []string{filepath.Join(dir, "*.gz")}, nil, true},
+		{"fails - missing file", []string{filepath.Join(dir, "missing.log")}, nil, true},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			files, err := Expand(tc.Inputs)
+			if (err != nil) != tc.Fails {
+				t.Fatalf("Expand: expected error %v received: %v", tc.Fails, err)
+			}
+			if !tc.Fails && !reflect.DeepEqual(files, tc.Wanted) {
+				t.Errorf("Expand: expected %v received: %v", tc.Wanted, files)
+			}
+		})
+	}
+}
+
+func TestOpen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte("compressed\n"))
+	zw.Close()
+
+	// the compression is detected from the content, whatever the name
+	ioutil.WriteFile(filepath.Join(dir, "app.log.1"), gz.Bytes(), 0644)
+	ioutil.WriteFile(filepath.Join(dir, "app.log"), []byte("plain\n"), 0644)
+
+	for name, want := range map[string]string{"app.log.1": "compressed\n", "app.log": "plain\n"} {
+		rc, err := Open(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Open(%v): expected no error received: %v", name, err)
+		}
+		b, _ := ioutil.ReadAll(rc)
+		rc.Close()
+		if string(b) != want {
+			t.Errorf("Open(%v): expected %q received: %q", name, want, b)
+		}
+	}
+}
+
+func TestOpenZstdError(t *testing.T) {
+	if _, err := exec.LookPath("zstd"); err != nil {
+		t.Skip("the zstd command isn't installed")
+	}
+	dir, err := ioutil.TempDir("", "pz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	name := filepath.Join(dir, "app.log.zst")
+	ioutil.WriteFile(name, append(zstdMagic, "corrupted"...), 0644)
+
+	rc, err := Open(name)
+	if err != nil {
+		t.Fatalf("Open: expected no error received: %v", err)
+	}
+	if _, err := ioutil.ReadAll(rc); err == nil || !strings.Contains(err.Error(), "zstd") {
+		t.Errorf("checkRead: expected the error of zstd received: %v", err)
+	}
+	if err := rc.Close(); err == nil {
+		t.Errorf("checkClose: expected the error of zstd")
+	}
+}
@@ -0,0 +1,121 @@
+// Package input reads the lines of the logs given to pz.
+package input
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// DefaultMaxLineSize is the size lines are truncated to by default.
+const DefaultMaxLineSize = 1 << 20
+
+// LineReader reads lines of any length, truncating the ones longer than its maximum size.
+// unlike bufio.Scanner, it never fails on a long line.
+type LineReader struct {
+	MaxSize int // lines are truncated to this many bytes, 0 means no limit
+
+	r       *bufio.Reader
+	buf     []byte
+	dropped int
+	partial bool // the last call stopped in the middle of the line in buf
+}
+
+// NewLineReader returns a reader of the lines of r, truncated to max bytes.
+func NewLineReader(r io.Reader, max int) *LineReader {
+	return &LineReader{
+		MaxSize: max,
+		r:       bufio.NewReaderSize(r, 64*1024),
+	}
+}
+
+// ReadLine returns the next line without its end of line, and the number of bytes dropped from it.
+// the line is only valid until the next call. at the end of the input, it returns io.EOF.
+// temporary errors of the input, like ErrIdle, are returned as they are and the line is read on
+// by the next call.
+func (lr *LineReader) ReadLine() ([]byte, int, error) {
+	if !lr.partial {
+		lr.buf, lr.dropped = lr.buf[:0], 0
+	}
+	lr.partial = false
+	read := len(lr.buf) > 0 || lr.dropped > 0
+
+	for {
+		chunk, err := lr.r.ReadSlice('\n')
+		read = read || len(chunk) > 0
+		if err == nil {
+			chunk = chunk[:len(chunk)-1]
+		}
+
+		if room := lr.MaxSize - len(lr.buf); lr.MaxSize > 0 && len(chunk) > room {
+			// cut at the start of a rune, so the kept part is still valid text
+			for room > 0 && !utf8.RuneStart(chunk[room]) {
+				room--
+			}
+			lr.buf = append(lr.buf, chunk[:room]...)
+			lr.dropped += len(chunk) - room
+		} else {
+			lr.buf = append(lr.buf, chunk...)
+		}
+
+		switch {
+		case err == nil:
+			return trimCR(lr.buf, lr.dropped), lr.dropped, nil
+		case err == bufio.ErrBufferFull:
+			continue
+		case err == io.EOF:
+			if read {
+				return trimCR(lr.buf, lr.dropped), lr.dropped, nil
+			}
+		case isTemporary(err):
+			lr.partial = true
+		}
+		return nil, 0, err
+	}
+}
+
+// isTemporary reports whether the error is temporary, like ErrIdle.
+func isTemporary(err error) bool {
+	te, ok := err.(interface {
+		Temporary() bool
+	})
+	return ok && te.Temporary()
+}
+
+// trimCR removes the carriage return of a line ending with CRLF.
+func trimCR(line []byte, dropped int) []byte {
+	if dropped == 0 && len(line) > 0 && line[len(line)-1] == '\r' {
+		return line[:len(line)-1]
+	}
+	return line
+}
+
+// ParseSize parses a size in bytes, with an optional unit like `512k`, `1MiB` or `2MB`.
+// units are powers of 1024.
+func ParseSize(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	i := len(s)
+	for i > 0 && (s[i-1] < '0' || s[i-1] > '9') {
+		i--
+	}
+
+	n, err := strconv.Atoi(s[:i])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+
+	switch strings.ToLower(strings.TrimSpace(s[i:])) {
+	case "", "b":
+		return n, nil
+	case "k", "kb", "kib":
+		return n << 10, nil
+	case "m", "mb", "mib":
+		return n << 20, nil
+	case "g", "gb", "gib":
+		return n << 30, nil
+	}
+	return 0, fmt.Errorf("invalid unit of size %q", s)
+}
@@ -0,0 +1,68 @@
+package input
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+
+	testScenarios := []struct {
+		Name    string
+		Input   string
+		Max     int
+		Lines   []string
+		Dropped []int
+	}{
+		{"pass - lines", "a\nb\r\n\nc", 0, []string{"a", "b", "", "c"}, []int{0, 0, 0, 0}},
+		{"pass - long line", long + "\nnext\n", 0, []string{long, "next"}, []int{0, 0}},
+		{"pass - truncated line", long + "\nnext\n", 1024, []string{long[:1024], "next"}, []int{len(long) - 1024, 0}},
+		{"pass - truncated at a rune", "aé\n", 2, []string{"a"}, []int{2}},
+		{"pass - empty input", "", 0, nil, nil},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			lr := NewLineReader(strings.NewReader(tc.Input), tc.Max)
+			var (
+				lines   []string
+				dropped []int
+			)
+			for {
+				l, d, err := lr.ReadLine()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("ReadLine: expected no error received: %v", err)
+				}
+				lines = append(lines, string(l))
+				dropped = append(dropped, d)
+			}
+
+			if len(lines) != len(tc.Lines) {
+				t.Fatalf("checkLines: expected %d lines received: %d", len(tc.Lines), len(lines))
+			}
+			for i := range lines {
+				if lines[i] != tc.Lines[i] || dropped[i] != tc.Dropped[i] {
+					t.Errorf("checkLine %d: expected %.20q (%d dropped) received: %.20q (%d dropped)", i, tc.Lines[i], tc.Dropped[i], lines[i], dropped[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	for s, want := range map[string]int{"100": 100, "512k": 512 << 10, "1MiB": 1 << 20, "2 MB": 2 << 20, "0": 0} {
+		if n, err := ParseSize(s); err != nil || n != want {
+			t.Errorf("ParseSize(%q): expected %d received: %d (%v)", s, want, n, err)
+		}
+	}
+	for _, s := range []string{"", "MB", "1TB", "-1"} {
+		if _, err := ParseSize(s); err == nil {
+			t.Errorf("ParseSize(%q): expected an error", s)
+		}
+	}
+}
@@ -1,53 +1,189 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
+	"io"
 	"os"
+	"sync"
 
 	"github.com/hadisinaee/pz/cmd"
+	"github.com/hadisinaee/pz/input"
 	"github.com/hadisinaee/pz/prettierzap"
 )
 
 func main() {
-	var (
-		level         string
-		timestamp     string
-		caller        string
-		keyValuePairs map[string]*string
-		emoji         bool
-	)
-
-	keyValuePairs = make(map[string]*string, 0)
+	var opts cmd.Options
+
 	cmd.InitCLI(cmd.CLIConfig{
 		Name:    "Prettier Zap",
 		Usage:   "make zap logs more beautiful and queryable",
 		Version: "0.9.2",
-	}, &level, &timestamp, &caller, keyValuePairs, &emoji)
+	}, &opts)
 	cmd.Run(os.Args)
 
-	scanner := bufio.NewScanner(os.Stdin)
+	printer := prettierzap.NewPrinter()
+	printer.Emoji = opts.Emoji
+	printer.MaxDepth = opts.MaxDepth
+	printer.MaxArrayItems = opts.MaxArrayItems
+	printer.TimeFormat = opts.TimeFormat
+	printer.Location = opts.Location
+	printer.Theme = opts.Theme
+	printer.ExpandGoroutines = opts.ExpandDumps
+	printer.Module = opts.Module
+	printer.MaxFrames = opts.StackFrames
+	printer.Output = opts.Output
+	printer.Columns = opts.Columns
+	printer.MetaOrder = opts.MetaOrder
+	printer.PinnedKeys = opts.PinnedKeys
+	printer.Fields = opts.Fields
+	printer.Hide = opts.Hide
+	if err := printer.SetTemplate(opts.Template); err != nil {
+		fmt.Fprintf(os.Stderr, "[(PZ) Template Error]= %+v\n", err)
+		os.Exit(1)
+	}
+
+	detector := prettierzap.KeyDetector{
+		Base:  prettierzap.DefaultKeyMapping(),
+		Fixed: opts.Keys,
+	}
+	if opts.DetectKeys {
+		detector.Lines = prettierzap.DefaultDetectLines
+	}
+
+	filter := prettierzap.LogFilter{
+		Level:    opts.Level,
+		MinLevel: opts.MinLevel,
+		MaxLevel: opts.MaxLevel,
+		Since:    opts.Since,
+		Until:    opts.Until,
+		Caller:   opts.Caller,
+		Logger:   opts.Logger,
+		Meta:     opts.KeyValuePairs,
+		Query:    opts.Query,
+	}
 
-	for scanner.Scan() {
-		l := scanner.Bytes()
-		if len(l) < 1 {
-			continue
+	// lines of JSON logs can be filtered before they're parsed
+	var prefilter *prettierzap.Prefilter
+	if opts.Format == prettierzap.FormatAuto || opts.Format == prettierzap.FormatJSON {
+		keys := detector.Base.Override(detector.Fixed)
+		prefilter = prettierzap.NewPrefilter(filter, &keys)
+	}
+
+	var readers []*prettierzap.LogReader
+	var inputs []io.Closer
+	for _, name := range opts.Inputs {
+		in, err := openInput(name, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "[(PZ) Open Error]= %+v\n", err)
+			os.Exit(1)
 		}
+		inputs = append(inputs, in)
 
-		pj, ok := prettierzap.ParseJSONByteArray(l)
-		if !ok {
-			fmt.Printf("[(PZ) cannot parse line]: %v", string(l))
+		r := prettierzap.NewLogReader(input.NewLineReader(in, opts.MaxLineSize), opts.Parser)
+		r.Detector = &detector
+		r.Prefilter = prefilter
+		r.KeepLines = opts.Output == prettierzap.OutputJSON
+		r.Workers = opts.Workers
+		if opts.Multiline {
+			r.Assembler = prettierzap.NewAssembler(opts.Parser)
+			r.Assembler.Start = opts.RecordStart
+			r.Assembler.KeepLines = r.KeepLines
 		}
+		if len(opts.Inputs) > 1 {
+			r.Source = name
+		}
+		readers = append(readers, r)
+	}
 
-		prettierzap.PrettyPrint(os.Stdout, pj, prettierzap.LogFilter{
-			Level:     level,
-			Timestamp: timestamp,
-			Caller:    caller,
-			Meta:      keyValuePairs,
-		}, emoji)
+	var next func() (prettierzap.ParsedJSON, error)
+	switch {
+	case opts.Follow:
+		next = follow(readers)
+	case len(readers) == 1:
+		next = readers[0].Next
+	default:
+		next = prettierzap.NewMergeReader(readers...).Next
 	}
 
-	if err := scanner.Err(); err != nil {
-		fmt.Printf("[(PZ) Scanner Error]= %+v", err)
+	err := printer.PrintRecords(os.Stdout, next, filter, opts.Workers)
+	// inputs like zstd files fail when closed if their command failed
+	for _, in := range inputs {
+		if cerr := in.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "[(PZ) Reader Error]= %+v\n", err)
+		os.Exit(1)
 	}
 }
+
+// openInput opens the input with the given name, to follow it in the follow mode.
+func openInput(name string, opts cmd.Options) (io.ReadCloser, error) {
+	if opts.Follow && name != input.Stdin {
+		return input.Follow(name, opts.Lines)
+	}
+	return input.Open(name)
+}
+
+// follow returns the records of the followed readers as they come, whichever file they come from.
+// it returns input.ErrIdle when no record is ready, before waiting for the next one.
+func follow(readers []*prettierzap.LogReader) func() (prettierzap.ParsedJSON, error) {
+	records := make(chan prettierzap.ParsedJSON, prettierzap.DefaultBatchSize)
+	var wg sync.WaitGroup
+	for _, r := range readers {
+		wg.Add(1)
+		go func(r *prettierzap.LogReader) {
+			defer wg.Done()
+			for {
+				pj, err := r.Next()
+				if isTemporary(err) {
+					continue
+				}
+				if err != nil {
+					if err != io.EOF {
+						fmt.Fprintf(os.Stderr, "[(PZ) Reader Error]= %+v\n", err)
+					}
+					return
+				}
+				records <- pj
+			}
+		}(r)
+	}
+
+	go func() {
+		wg.Wait()
+		close(records)
+	}()
+
+	idle := false
+	return func() (prettierzap.ParsedJSON, error) {
+		if !idle {
+			select {
+			case pj, ok := <-records:
+				if !ok {
+					return nil, io.EOF
+				}
+				return pj, nil
+			default:
+				idle = true
+				return nil, input.ErrIdle
+			}
+		}
+
+		idle = false
+		pj, ok := <-records
+		if !ok {
+			return nil, io.EOF
+		}
+		return pj, nil
+	}
+}
+
+// isTemporary reports whether the error is temporary, like input.ErrIdle.
+func isTemporary(err error) bool {
+	te, ok := err.(interface {
+		Temporary() bool
+	})
+	return ok && te.Temporary()
+}
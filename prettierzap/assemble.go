@@ -0,0 +1,182 @@
+package prettierzap
+
+import (
+	"regexp"
+	"strings"
+)
+
+// DefaultMaxRecordLines is the number of lines a record can have before it is cut.
+const DefaultMaxRecordLines = 1000
+
+// panicStart matches the first line of a Go panic or runtime error, which starts a record of its own
+// instead of being a continuation of the preceding log.
+var panicStart = regexp.MustCompile(`^(panic: |fatal error: |SIGQUIT: |SIGSEGV: )`)
+
+// Assembler groups the lines of the input into records.
+// a record starts with a line of a logger and goes on with the lines that aren't, like the stacktrace
+// written after a line of zap's console encoder or the goroutine dump of a panic.
+// the continuation lines of a record are kept as its stacktrace.
+// lines before the first record, and a panic with its dump, become records of their own;
+// panics and goroutine dumps are parsed into fatal records carrying their goroutines.
+type Assembler struct {
+	Parser    Parser         // parser of the lines that start records
+	Start     *regexp.Regexp // lines matching it start records, lines the parser reads do if nil
+	MaxLines  int            // records are cut after this many lines, 0 means no limit
+	KeepLines bool           // label the records with their lines, see LineOf
+
+	head     ParsedJSON  // parsed first line of the record, nil if it isn't a line of a logger
+	first    []byte      // first line of the record
+	keys     *KeyMapping // key mapping of the first line of the record
+	lines    []string    // continuation lines of the record
+	dropped  int         // bytes dropped from the first line of the record
+	skipped  int         // continuation lines of a rejected record
+	rejected bool        // the record is filtered out, see addRejected
+	isOpen   bool
+}
+
+// NewAssembler returns an assembler of records parsed with the given parser.
+func NewAssembler(p Parser) *Assembler {
+	return &Assembler{
+		Parser:   p,
+		MaxLines: DefaultMaxRecordLines,
+	}
+}
+
+// Add adds the next line of the input.
+// it returns the previous record when the line starts a new one.
+func (a *Assembler) Add(line []byte) (ParsedJSON, bool) {
+	return a.AddTruncated(line, 0)
+}
+
+// AddTruncated adds the next line of the input, that the input reader cut after dropped bytes.
+// the core fields of the records are read with the default key mapping.
+// it returns the previous record when the line starts a new one.
+func (a *Assembler) AddTruncated(line []byte, dropped int) (ParsedJSON, bool) {
+	return a.add(line, dropped, defaultKeys)
+}
+
+// add adds the next line of the input like AddTruncated, reading the core fields with the key mapping.
+func (a *Assembler) add(line []byte, dropped int, km *KeyMapping) (ParsedJSON, bool) {
+	head, isStart := a.parseStart(line, dropped, km)
+	return a.addParsed(line, dropped, head, isStart, km)
+}
+
+// addParsed adds a line already parsed by parseStart, which can run on other goroutines.
+func (a *Assembler) addParsed(line []byte, dropped int, head ParsedJSON, isStart bool, km *KeyMapping) (ParsedJSON, bool) {
+	full := a.MaxLines > 0 && len(a.lines)+a.skipped+1 >= a.MaxLines
+	if !isStart && a.isOpen && !full && (a.head == nil && !a.rejected || !panicStart.Match(line)) {
+		if a.rejected {
+			a.skipped++
+			return nil, false
+		}
+		l := string(line)
+		if dropped > 0 {
+			l += truncationMarker(dropped)
+		}
+		a.lines = append(a.lines, l)
+		return nil, false
+	}
+
+	if !isStart && !a.isOpen && len(strings.TrimSpace(string(line))) == 0 && dropped == 0 {
+		return nil, false
+	}
+
+	pj, ok := a.Flush()
+	a.head = head
+	a.first = append([]byte(nil), line...)
+	a.keys = km
+	a.dropped = dropped
+	a.isOpen = true
+	return pj, ok
+}
+
+// addRejected adds a line of a logger starting a record that is filtered out, like a line rejected
+// by a prefilter, so the record is dropped with its continuation lines without being parsed.
+// it returns the previous record.
+func (a *Assembler) addRejected() (ParsedJSON, bool) {
+	pj, ok := a.Flush()
+	a.isOpen, a.rejected = true, true
+	return pj, ok
+}
+
+// Flush returns the record being assembled, if any, and forgets it.
+func (a *Assembler) Flush() (ParsedJSON, bool) {
+	if !a.isOpen {
+		return nil, false
+	}
+	if a.rejected {
+		a.skipped, a.isOpen, a.rejected = 0, false, false
+		return nil, false
+	}
+
+	pj := a.head
+	if pj == nil {
+		if dump, ok := newPanicLog(string(a.first), a.lines, a.keys); ok {
+			pj = dump
+		} else {
+			pj, _ = parseText(a.first, a.keys)
+			markTruncated(pj, a.dropped)
+		}
+	}
+	if pl, ok := asParsedLog(pj); ok && len(a.lines) > 0 {
+		attachLines(pl, a.lines, mappingOf(pj))
+	}
+	if a.KeepLines {
+		pj = withLine(pj, strings.Join(append([]string{string(a.first)}, a.lines...), "\n"))
+	}
+
+	a.head, a.first, a.keys, a.lines, a.dropped, a.isOpen = nil, nil, nil, nil, 0, false
+	return pj, true
+}
+
+// parseStart parses the line if it starts a record.
+// it doesn't change the assembler, so lines can be parsed concurrently before being added.
+func (a *Assembler) parseStart(line []byte, dropped int, km *KeyMapping) (ParsedJSON, bool) {
+	if a.Start != nil {
+		if !a.Start.Match(line) {
+			return nil, false
+		}
+		pj, _ := parseTruncatedLine(a.Parser, line, dropped, km)
+		return pj, true
+	}
+
+	var (
+		pj  ParsedJSON
+		err error
+	)
+	if dropped > 0 {
+		pj, err = parseTruncated(a.Parser, line, km)
+	} else {
+		pj, err = parseKeys(a.Parser, line, km)
+	}
+	if err != nil {
+		return nil, false
+	}
+	markTruncated(pj, dropped)
+	return pj, true
+}
+
+// attachLines adds continuation lines to the stacktrace of the log.
+func attachLines(pl parsedLog, lines []string, km *KeyMapping) {
+	// blank lines at the end of a dump separate it from the next record
+	for len(lines) > 0 && strings.TrimSpace(lines[len(lines)-1]) == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	key := first(km.Stacktrace, "stacktrace")
+	for _, k := range km.Stacktrace {
+		if _, ok := pl[k]; ok {
+			key = k
+			break
+		}
+	}
+
+	text := strings.Join(lines, "\n")
+	if st, ok := pl[key]; ok {
+		text = valueOf(st).String() + "\n" + text
+	}
+	pl[key] = jsonQuote(text)
+}
@@ -0,0 +1,96 @@
+package prettierzap
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// assemble runs the lines through an assembler and returns the messages and stacktraces of the records.
+func assemble(a *Assembler, input string) (msgs, stacks []string) {
+	add := func(pj ParsedJSON, ok bool) {
+		if !ok {
+			return
+		}
+		msgs = append(msgs, msgOf(pj))
+		st, _ := pj.GetValue("stacktrace")
+		stacks = append(stacks, st.String())
+	}
+	for _, l := range strings.Split(input, "\n") {
+		add(a.Add([]byte(l)))
+	}
+	add(a.Flush())
+	return msgs, stacks
+}
+
+func TestAssembler(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		Start  string
+		Input  string
+		Msgs   []string
+		Stacks []string
+	}{
+		{
+			"pass - one record per log",
+			"",
+			`{"level":"info","msg":"a"}` + "\n" + `{"level":"info","msg":"b"}`,
+			[]string{"a", "b"},
+			[]string{"", ""},
+		},
+		{
+			"pass - console stacktrace",
+			"",
+			"2018-03-30T16:09:05.187Z\tERROR\tmain.go:12\tfailed\nmain.main\n\t/src/main.go:12\n" + `{"level":"info","msg":"next"}`,
+			[]string{"failed", "next"},
+			[]string{"main.main\n\t/src/main.go:12", ""},
+		},
+		{
+			"pass - panic after a log",
+			"",
+			`{"level":"info","msg":"a"}` + "\npanic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:8 +0x1d\n",
+			[]string{"a", "panic: boom"},
+			[]string{"", "\ngoroutine 1 [running]:\nmain.main()\n\t/src/main.go:8 +0x1d"},
+		},
+		{
+			"pass - multi-line print before the first log",
+			"",
+			"{\n  \"a\": 1\n}\n" + `{"level":"info","msg":"b"}`,
+			[]string{"{", "b"},
+			[]string{"  \"a\": 1\n}", ""},
+		},
+		{
+			"pass - start pattern",
+			`^\d{4}-`,
+			"2018-03-30 first\nmore\n2018-03-30 second",
+			[]string{"2018-03-30 first", "2018-03-30 second"},
+			[]string{"more", ""},
+		},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			a := NewAssembler(keyedParser(parseAuto))
+			if tc.Start != "" {
+				a.Start = regexp.MustCompile(tc.Start)
+			}
+			msgs, stacks := assemble(a, tc.Input)
+			if strings.Join(msgs, "|") != strings.Join(tc.Msgs, "|") {
+				t.Errorf("checkMsgs: expected %q received: %q", tc.Msgs, msgs)
+			}
+			if strings.Join(stacks, "|") != strings.Join(tc.Stacks, "|") {
+				t.Errorf("checkStacks: expected %q received: %q", tc.Stacks, stacks)
+			}
+		})
+	}
+}
+
+func TestAssemblerMaxLines(t *testing.T) {
+	a := NewAssembler(keyedParser(parseAuto))
+	a.MaxLines = 3
+
+	msgs, _ := assemble(a, "panic: boom\n1\n2\n3\n4")
+	if len(msgs) != 2 || msgs[1] != "3" {
+		t.Errorf("checkMsgs: expected the record to be cut after 3 lines received: %q", msgs)
+	}
+}
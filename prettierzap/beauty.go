@@ -4,38 +4,50 @@ import (
 	"bytes"
 	"fmt"
 	"io"
-	"strconv"
 	"strings"
 	"time"
 
-	"github.com/fatih/color"
+	"github.com/hadisinaee/pz/query"
 )
 
 // ParsedJSON represents a parsed zap json object.
+// the Get{Level,Timestamp,Caller,Msg,Name,Meta} getters return the raw JSON text of the fields,
+// the typed getters return decoded values.
 type ParsedJSON interface {
 	GetLevel() string
 	GetTimestamp() string
 	GetCaller() string
 	GetMsg() string
+	GetName() string
 	GetMeta() map[string]string
+	GetTime() (time.Time, error)
+
+	GetValue(key string) (Value, bool)
+	GetString(key string) (string, bool)
+	GetNumber(key string) (float64, bool)
+	GetBool(key string) (bool, bool)
+	IsNull(key string) bool
+	GetObject(key string) ([]Member, bool)
+	GetArray(key string) ([]Value, bool)
+
+	// Lookup resolves a dotted field path like `http.request.id` or `tags[0]`
+	Lookup(path string) (Value, bool)
 }
 
 // LogFilter represents a filter that is used for filter logs based on specific fields
 type LogFilter struct {
-	Level     string
-	Timestamp string
+	Level     string    // comma separated levels, e.g. `error,fatal`
+	MinLevel  string    // just logs at least as severe as this level
+	MaxLevel  string    // just logs at most as severe as this level
+	Timestamp string    // just logs after this timestamp(>=)
+	Since     time.Time // just logs at or after this time, if set
+	Until     time.Time // just logs before this time, if set
 	Caller    string
+	Logger    []string // just logs of loggers matching any of these glob patterns, e.g. `api.*`
 	Meta      map[string]*string
+	Query     *query.Query // just logs that match the query expression
 }
 
-var (
-	fgCyan              = color.New(color.FgCyan).SprintfFunc()
-	bgYellowfgBlackBold = color.New(color.BgYellow, color.FgBlack, color.Bold).SprintfFunc()
-	bgYellowfgBlack     = color.New(color.BgYellow, color.FgBlack).SprintfFunc()
-	fgYellow            = color.New(color.FgYellow).SprintfFunc()
-	fgRed               = color.New(color.FgRed).SprintfFunc()
-)
-
 const (
 	debugLevel   = `debug`
 	warningLevel = `warn`
@@ -46,75 +58,131 @@ const (
 	fatalLevel   = `fatal`
 )
 
-// findKey search the given data array for a key which ends with a `"`.
-func findKey(data []byte) (string, int) {
-	k := make([]byte, 0)
-	for i, b := range data {
-		if b == '"' {
-			return string(k), i
-		}
-		k = append(k, b)
-	}
-	return "", 0
+// levelOf returns the decoded level of the log.
+func levelOf(pj ParsedJSON) string {
+	return valueOf(pj.GetLevel()).String()
+}
+
+// callerOf returns the decoded caller of the log.
+func callerOf(pj ParsedJSON) string {
+	return valueOf(pj.GetCaller()).String()
+}
+
+// msgOf returns the decoded message of the log.
+func msgOf(pj ParsedJSON) string {
+	return valueOf(pj.GetMsg()).String()
 }
 
-// findValue search the given data array for a value which ends with a `,` or a `}`.
-func findValue(data []byte) (string, int) {
-	v := make([]byte, 0)
-	for i, b := range data {
-		if b == ',' || b == '}' {
-			return string(v), i
+// compareValues compares two values, numerically if both are numbers and textually otherwise.
+func compareValues(a, b Value) int {
+	an, aok := a.AsNumber()
+	bn, bok := b.AsNumber()
+	if aok && bok {
+		switch {
+		case an < bn:
+			return -1
+		case an > bn:
+			return 1
 		}
-		v = append(v, b)
+		return 0
 	}
-	return "", 0
+	return strings.Compare(a.String(), b.String())
 }
 
 // filterJSON filters jsons based on the given log filter object.
 func filterJSON(pj ParsedJSON, f LogFilter) bool {
 	pp := true
 
-	if f.Level != "" && strings.Replace(pj.GetLevel(), "\"", "", -1) != f.Level {
+	if !filterLevel(pj, f) {
+		pp = false
+	} else if f.Caller != "" && !strings.Contains(callerOf(pj), f.Caller) {
 		pp = false
-	} else if f.Caller != "" && !strings.Contains(pj.GetCaller(), f.Caller) {
+	} else if !filterLogger(pj, f) {
 		pp = false
-	} else if f.Timestamp != "" && pj.GetTimestamp() < f.Timestamp {
+	} else if f.Timestamp != "" && compareTimestamps(pj, f.Timestamp) < 0 {
+		pp = false
+	} else if !filterTimeRange(pj, f) {
 		pp = false
 	}
 
-	pMeta := pj.GetMeta()
 	for kf, vf := range f.Meta {
-		if vp, ok := pMeta[kf]; !ok {
+		if vp, ok := pj.Lookup(kf); !ok {
 			pp = false
 			break
-		} else if vp != *vf {
+		} else if !vp.Equal(valueOf(*vf)) {
 			pp = false
 			break
 		}
 	}
+
+	if pp && f.Query != nil && !f.Query.Match(queryRecord{pj}) {
+		pp = false
+	}
 	return pp
 }
 
+// Printer represents the configuration used for rendering parsed logs.
+type Printer struct {
+	Emoji            bool           // add some funny emoji to output
+	MaxDepth         int            // nested fields deeper than this are collapsed into one line, 0 means no limit
+	MaxArrayItems    int            // arrays longer than this are truncated with a summary, 0 means no limit
+	TimeFormat       string         // Go layout of the timestamps, or one of TimeFormatRelative and TimeFormatDelta
+	Output           string         // format of the output, one of the Output constants, OutputPretty if empty
+	Columns          []string       // fields written by the csv and tsv outputs, DefaultColumns if empty
+	MetaOrder        string         // order of the meta fields, one of the MetaOrder constants, MetaOrderOriginal if empty
+	PinnedKeys       []string       // meta fields written first, in this order
+	Fields           []string       // patterns of the meta fields shown, like `http.*`, all of them if empty
+	Hide             []string       // patterns of the meta fields hidden
+	ExpandGoroutines bool           // write the stacks of all goroutines of dumps, not just of the one that panicked
+	Module           string         // import path of the application, its frames are highlighted in stacktraces
+	MaxFrames        int            // stacktraces show just this many frames of the application, 0 means all frames
+	Location         *time.Location // time zone of the timestamps, local time if nil
+	Theme            *Theme         // styles of the pretty output, the dark theme if nil
+
+	now      func() time.Time // current time for relative timestamps, time.Now if nil
+	template *recordTemplate  // template of the pretty output, the built-in layout if nil, see SetTemplate
+	prevTime time.Time        // time of the previous printed log for delta timestamps
+}
+
+// NewPrinter returns a printer with the default configuration.
+func NewPrinter() *Printer {
+	return &Printer{
+		MaxDepth:      DefaultMaxDepth,
+		MaxArrayItems: DefaultMaxArrayItems,
+		TimeFormat:    DefaultTimeFormat,
+		Location:      time.Local,
+	}
+}
+
 // GenerateOutputString generates the formatted output string for the given parsed JSON.
 func GenerateOutputString(pj ParsedJSON, emoji bool) (string, error) {
+	p := NewPrinter()
+	p.Emoji = emoji
+	return p.GenerateOutputString(pj)
+}
+
+// GenerateOutputString generates the formatted output string for the given parsed JSON.
+func (p *Printer) GenerateOutputString(pj ParsedJSON) (string, error) {
 	var (
-		l = pj.GetLevel()
-		s = ""
-		e error
+		emoji      = p.Emoji
+		theme      = p.theme()
+		l          = levelOf(pj)
+		sev, known = ParseSeverity(l)
+		s          = ""
+		e          error
 	)
 
 	if pj.GetTimestamp() != "" {
-		tss := strings.Split(pj.GetTimestamp(), ".")[0]
-
-		ts, errParse := strconv.ParseInt(tss, 10, 64)
-		if errParse != nil {
-			return "", errParse
+		// timestamps that can't be read are shown as they are
+		ts := valueOf(pj.GetTimestamp()).String()
+		if t, errTime := pj.GetTime(); errTime == nil {
+			ts = p.formatTime(t)
 		}
 
 		if emoji {
-			s = fmt.Sprintf("%s %s ", "\U000023F0", bgYellowfgBlack("%-20s", time.Unix(ts, 0).Format("02/01/2006 15:04:05")))
+			s = fmt.Sprintf("%s %s ", "\U000023F0", theme.style("timestamp").Sprintf("%-20s", ts))
 		} else {
-			s = bgYellowfgBlack("%-20s| ", time.Unix(ts, 0).Format("02/01/2006 15:04:05"))
+			s = theme.style("timestamp").Sprintf("%-20s| ", ts)
 		}
 	}
 
@@ -122,160 +190,109 @@ func GenerateOutputString(pj ParsedJSON, emoji bool) (string, error) {
 		var emojiChar string
 
 		if emoji {
-			switch l {
-			case `"info"`:
+			switch sev {
+			case InfoSeverity:
 				// pagger
 				emojiChar = "\U0001F4DF"
-			case `"warn"`:
+			case WarnSeverity:
 				// warning
 				emojiChar = "\U000026A0 "
-			case `"error"`:
+			case ErrorSeverity:
 				// alarm
 				emojiChar = "\U0001F6A8"
-			case `"panic"`, `"dpanic"`:
+			case PanicSeverity, DPanicSeverity:
 				// pile of poo
 				emojiChar = "\U0001F4A9"
-			case `"fatal"`:
+			case FatalSeverity:
 				// skull
 				emojiChar = "\U00002620 "
-			case `"debug"`:
+			case DebugSeverity:
 				// high voltage
 				// emojiChar = "\U000026A1"
 				// eyes
 				emojiChar = "\U0001F440"
 			}
-			s = s + fmt.Sprintf("%s %s", emojiChar, bgYellowfgBlackBold(" %-8s", strings.Replace(strings.ToUpper(l), "\"", "", -1)))
+			s = s + fmt.Sprintf("%s %s", emojiChar, theme.levelStyle("level", sev, known).Sprintf(" %-8s", strings.ToUpper(l)))
 		} else {
-			s = s + bgYellowfgBlackBold(" %-8s", strings.Replace(strings.ToUpper(l), "\"", "", -1))
+			s = s + theme.levelStyle("level", sev, known).Sprintf(" %-8s", strings.ToUpper(l))
 		}
 	}
 
+	if src := SourceOf(pj); src != "" {
+		s = s + theme.style("source").Sprintf(" %s", src)
+	}
+
+	if name := nameOf(pj); name != "" {
+		s = s + loggerColor(name)(" <%s>", name)
+	}
+
 	if pj.GetCaller() != "" {
 		if emoji {
-			s = s + fmt.Sprintf(" %s%s", "\U0001F5E3", fgCyan(" [%s]", pj.GetCaller()))
+			s = s + fmt.Sprintf(" %s%s", "\U0001F5E3", theme.style("caller").Sprintf(" [%s]", callerOf(pj)))
 		} else {
-			s = s + fgCyan(" @[%s]", pj.GetCaller())
+			s = s + theme.style("caller").Sprintf(" @[%s]", callerOf(pj))
 		}
 
 	}
 
-	l = strings.Replace(l, "\"", "", -1)
-	if l == debugLevel || l == warningLevel {
-		s = s + " " + fgYellow(pj.GetMsg())
-	} else if l == fatalLevel || l == errorLevel || l == dPanicLevel || l == panicLevel {
-		s = s + " " + fgRed(pj.GetMsg())
-	} else {
-		s = s + " " + pj.GetMsg()
-	}
+	s = s + " " + theme.levelStyle("message", sev, known).Sprintf("%s", msgOf(pj))
 
 	s += "\n"
 
-	if len(pj.GetMeta()) > 0 {
+	if meta := p.meta(pj); len(meta) > 0 {
 		var m bytes.Buffer
-		var r string
-		meta := pj.GetMeta()
-
-		st, ok := meta["stacktrace"]
-		if ok {
-			st = strings.Replace(st, "\\n\\t", "\U0000000A\U00000009\U00000009> ", -1)
-			st = strings.Replace(st, "\\n", "\U0000000A\U00000009\U00000009 ", -1)
-			st = st[1 : len(st)-1]
-
-			r = fmt.Sprintf("\t%v: \n\t\t%s\n", fgRed("%q", "stacktrace"), fgRed("> %s", st))
-			m.WriteString(r)
-		}
-		for key := range meta {
-			if key != "stacktrace" {
-				r = fmt.Sprintf("   %v: %s\n", fgCyan("%q", key), meta[key])
-				m.WriteString(r)
-			}
-		}
+		p.writeStack(&m, pj, meta)
+		p.writeMeta(&m, pj, meta)
 		s = fmt.Sprintf("%s%s\n", s, m.String())
 	}
 	return s, e
 }
 
-// ParseJSONByteArray parses the given byte array and creates a ParsedJSON object.
-func ParseJSONByteArray(jsonByte []byte) (ParsedJSON, bool) {
-	if len(jsonByte) == 0 {
-		return nil, false
+// writeStack writes the stacktrace of the record, or the goroutines of its dump,
+// and removes the fields they were read from from the meta fields.
+func (p *Printer) writeStack(b *bytes.Buffer, pj ParsedJSON, meta map[string]string) {
+	stKey := mappingOf(pj).stacktraceKey(meta)
+	if d, isDump := unwrapLog(pj).(dumper); isDump {
+		p.writeDump(b, d.Dump())
+	} else if sv, ok := pj.GetValue(stKey); stKey != "" && ok {
+		p.writeStacktrace(b, stKey, sv.String())
 	}
+	dropStack(pj, meta)
+}
 
-	var (
-		pl         = parsedLog{}
-		offset     = 0
-		nextOffset = 0
-		byteLength = len(jsonByte)
-		hasStart   = false
-		hasEnd     = false
-		foundKey   = ""
-	)
-
-	// check for a valid JSON string(encapsulated between {})
-	// search in the byte array from both ends looking for `{` and `}`
-	// if it has both `{` and `}`, it is a JSON byte array
-	for i := 0; i < byteLength; i++ {
-		if !hasStart {
-			switch jsonByte[i] {
-			case ' ', '\t':
-				continue
-			case '{':
-				hasStart = true
-				offset = i
-			default:
-				hasStart = false
-			}
-		}
-
-		if !hasEnd {
-			switch jsonByte[byteLength-i-1] {
-			case ' ', '\t':
-				continue
-			case '}':
-				hasEnd = true
-				byteLength = byteLength - i - 1
-			default:
-				hasEnd = false
-			}
-		}
-
-		if (hasEnd && hasStart) || (i >= byteLength-i-1) {
-			break
-		}
+// dropStack removes the fields of the stacktrace of the record, or of its dump, from the meta fields.
+func dropStack(pj ParsedJSON, meta map[string]string) {
+	if _, isDump := unwrapLog(pj).(dumper); isDump {
+		delete(meta, "goroutines")
 	}
+	delete(meta, mappingOf(pj).stacktraceKey(meta))
+}
 
-	// if it isn't a valid JSON, treat it as a debug level message
-	if !(hasEnd && hasStart) {
-		pl["level"] = fmt.Sprintf("%q", debugLevel)
-		pl["ts"] = fmt.Sprintf("%v", time.Now().Unix())
-		pl["caller"] = `"user-code"`
-		pl["msg"] = strings.TrimSpace(string(jsonByte))
-		return pl, true
+// writeMeta writes the meta fields of the record, one per line, in the order of the printer.
+func (p *Printer) writeMeta(b *bytes.Buffer, pj ParsedJSON, meta map[string]string) {
+	for _, key := range p.metaKeys(pj, meta) {
+		p.writeField(b, p.theme().style("key").Sprintf("%q", key), valueOf(meta[key]), treeIndent, 0)
 	}
+}
 
-	// search for key-value pairs inside the byte array
-	for offset < byteLength {
-		switch jsonByte[offset] {
-		case '"':
-			k, no := findKey(jsonByte[offset+1:])
-			foundKey = strings.TrimSpace(string(k))
-			nextOffset = no + 1
-		case ':':
-			v, no := findValue(jsonByte[offset+1:])
-			pl[foundKey] = strings.TrimSpace(string(v))
-			nextOffset = no + 1
-		}
-
-		offset = offset + nextOffset + 1
-		nextOffset = 0
-	}
-	return pl, true
+// ParseJSONByteArray parses the given byte array and creates a ParsedJSON object.
+// if it isn't a valid JSON, it is treated as a debug level message.
+func ParseJSONByteArray(jsonByte []byte) (ParsedJSON, bool) {
+	return ParseLine(keyedParser(parseJSON), jsonByte)
 }
 
 // PrettyPrint writes the pretty version of the parsed JSON in the given writer.
 func PrettyPrint(w io.Writer, pj ParsedJSON, f LogFilter, emoji bool) error {
+	p := NewPrinter()
+	p.Emoji = emoji
+	return p.PrettyPrint(w, pj, f)
+}
+
+// PrettyPrint writes the pretty version of the parsed JSON in the given writer,
+// or the version of the output format of the printer.
+func (p *Printer) PrettyPrint(w io.Writer, pj ParsedJSON, f LogFilter) error {
 	if filterJSON(pj, f) {
-		t, err := GenerateOutputString(pj, emoji)
+		t, err := p.render(pj)
 		if err != nil {
 			return err
 		}
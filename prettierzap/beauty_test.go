@@ -5,6 +5,8 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+
+	"github.com/hadisinaee/pz/query"
 )
 
 func TestParseJSON(t *testing.T) {
@@ -150,6 +152,72 @@ func TestParseJSON(t *testing.T) {
 				checkMeta("folder_path", `"./keys/"`, "foo", `"bar"`),
 			),
 		},
+		{
+			"pass - parse one line json with separators and escaped quotes inside strings",
+			false,
+			func() []byte {
+				return []byte(`{"level":"warn","msg":"retrying, got \"}\" from upstream","url":"http://localhost/a,b"}`)
+			},
+			checks(
+				checkTruth(true),
+				checkLevel(`"warn"`),
+				checkMsg(`"retrying, got \"}\" from upstream"`),
+				checkMeta("url", `"http://localhost/a,b"`),
+			),
+		},
+		{
+			"pass - parse one line json with nested objects and arrays",
+			false,
+			func() []byte {
+				return []byte(`{"level":"info","msg":"request served","http":{"status":200,"request":{"id":"abc","tags":["a","b"]}},"ids":[1, 2, {"x": null}],"ok":true}`)
+			},
+			checks(
+				checkTruth(true),
+				checkLevel(`"info"`),
+				checkMsg(`"request served"`),
+				checkMeta(
+					"http", `{"status":200,"request":{"id":"abc","tags":["a","b"]}}`,
+					"ids", `[1, 2, {"x": null}]`,
+					"ok", "true",
+				),
+			),
+		},
+		{
+			"pass - parse one line json with escaped keys",
+			false,
+			func() []byte {
+				return []byte(` {"level" : "info", "msg":"x", "a\"b\u00e9" : 1} `)
+			},
+			checks(
+				checkTruth(true),
+				checkLevel(`"info"`),
+				checkMeta("a\"b\u00e9", "1"),
+			),
+		},
+		{
+			"pass - parse one line broken json as a raw line",
+			false,
+			func() []byte {
+				return []byte(`{"level":"info","msg":"unterminated}`)
+			},
+			checks(
+				checkTruth(true),
+				checkLevel(`"debug"`),
+				checkMsg(`{"level":"info","msg":"unterminated}`),
+			),
+		},
+		{
+			"pass - parse one line json array as a raw line",
+			false,
+			func() []byte {
+				return []byte(`[{"level":"info"}]`)
+			},
+			checks(
+				checkTruth(true),
+				checkLevel(`"debug"`),
+				checkMsg(`[{"level":"info"}]`),
+			),
+		},
 	}
 
 	for _, tc := range testScenarios {
@@ -648,6 +716,124 @@ func TestFilterJSON(t *testing.T) {
 			},
 			checks(checkFiltered([]bool{true, false, false})),
 		},
+		{
+			"pass pretty print - filters timestamps numerically",
+			false,
+			func() ([]ParsedJSON, LogFilter) {
+				p := []ParsedJSON{
+					parsedLog{
+						"level": `"info"`,
+						"ts":    "999999999.5",
+						"msg":   `"before"`,
+					},
+					parsedLog{
+						"level": `"info"`,
+						"ts":    "1000000000.5",
+						"msg":   `"after"`,
+					},
+				}
+
+				f := LogFilter{
+					Timestamp: "1000000000",
+				}
+				return p, f
+			},
+			checks(checkFiltered([]bool{false, true})),
+		},
+		{
+			"pass pretty print - filters decoded values",
+			false,
+			func() ([]ParsedJSON, LogFilter) {
+				p := []ParsedJSON{
+					parsedLog{
+						"level": `"info"`,
+						"msg":   `"connecting to the database"`,
+						"token": "1234.0",
+						"name":  `"caf\u00e9"`,
+					},
+					parsedLog{
+						"level": `"info"`,
+						"msg":   `"connecting to the database"`,
+						"token": `"1234"`,
+						"name":  `"café"`,
+					},
+				}
+
+				m := make(map[string]*string, 2)
+				t := "1234"
+				n := `"café"`
+				m["token"] = &t
+				m["name"] = &n
+				f := LogFilter{
+					Meta: m,
+				}
+				return p, f
+			},
+			checks(checkFiltered([]bool{true, false})),
+		},
+		{
+			"pass pretty print - filters nested fields by path",
+			false,
+			func() ([]ParsedJSON, LogFilter) {
+				p := []ParsedJSON{
+					parsedLog{
+						"level": `"error"`,
+						"msg":   `"request failed"`,
+						"http":  `{"status":500,"request":{"id":"abc"}}`,
+						"tags":  `["x","y"]`,
+					},
+					parsedLog{
+						"level": `"info"`,
+						"msg":   `"request served"`,
+						"http":  `{"status":200,"request":{"id":"def"}}`,
+						"tags":  `["x"]`,
+					},
+				}
+
+				m := make(map[string]*string, 2)
+				st := "500"
+				tag := `"x"`
+				m["http.status"] = &st
+				m["tags[0]"] = &tag
+				f := LogFilter{
+					Meta: m,
+				}
+				return p, f
+			},
+			checks(checkFiltered([]bool{true, false})),
+		},
+		{
+			"pass pretty print - filters by query expression",
+			false,
+			func() ([]ParsedJSON, LogFilter) {
+				p := []ParsedJSON{
+					parsedLog{
+						"level":  `"error"`,
+						"caller": `"api/handler.go:10"`,
+						"msg":    `"request failed"`,
+						"http":   `{"status":503}`,
+					},
+					parsedLog{
+						"level":  `"warn"`,
+						"caller": `"api/healthcheck.go:5"`,
+						"msg":    `"slow"`,
+						"http":   `{"status":500}`,
+					},
+					parsedLog{
+						"level":   `"info"`,
+						"caller":  `"db/conn.go:1"`,
+						"message": `"connection timeout"`,
+					},
+				}
+
+				q, _ := query.Parse(`level in (error, warn) && http.status >= 500 && !(caller ~ "healthcheck") || msg contains "timeout"`)
+				f := LogFilter{
+					Query: q,
+				}
+				return p, f
+			},
+			checks(checkFiltered([]bool{true, false, true})),
+		},
 	}
 
 	for _, tc := range testScenarios {
@@ -0,0 +1,68 @@
+package prettierzap
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+var errNotConsole = errors.New("not a zap console line")
+
+// ansiEscape matches the color codes of colored level encoders like zapcore.CapitalColorLevelEncoder.
+var ansiEscape = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// callerPattern matches callers of zap's caller encoders, like `pkg/file.go:12`.
+var callerPattern = regexp.MustCompile(`^\S+\.go:\d+$`)
+
+// parseConsole parses a line of zap's console encoder, tab separated fields of
+// time, level, logger name, caller and message, followed by the context fields as a JSON object:
+//
+//	2018-03-30T16:09:05.187+0430	INFO	api	http/server.go:42	request served	{"status": 200}
+//
+// the logger name and the caller are written only when they're set.
+func parseConsole(line []byte, km *KeyMapping) (ParsedJSON, error) {
+	fields := strings.Split(strings.TrimRight(string(line), "\r\n"), "\t")
+	if len(fields) < 3 {
+		return nil, errNotConsole
+	}
+
+	ts := strings.TrimSpace(fields[0])
+	tv := valueOf(ts)
+	if tv.Kind() != NumberKind {
+		tv = valueOf(jsonQuote(ts))
+	}
+	if _, err := ParseTime(tv, TimeEncodingAuto); err != nil {
+		return nil, errNotConsole
+	}
+
+	level := strings.TrimSpace(ansiEscape.ReplaceAllString(fields[1], ""))
+	if _, ok := ParseSeverity(level); !ok {
+		return nil, errNotConsole
+	}
+
+	pl := parsedLog{}
+	pl[first(km.Time, "ts")] = tv.Raw()
+	pl[first(km.Level, "level")] = jsonQuote(level)
+
+	var keys []string
+	rest := fields[2:]
+	if last := rest[len(rest)-1]; len(rest) > 1 && strings.HasPrefix(last, "{") {
+		if ctx, err := scanLog([]byte(last), km); err == nil {
+			for k, v := range ctx.parsedLog {
+				pl[k] = v
+			}
+			keys = ctx.keys
+			rest = rest[:len(rest)-1]
+		}
+	}
+
+	pl[first(km.Message, "msg")] = jsonQuote(rest[len(rest)-1])
+	for _, f := range rest[:len(rest)-1] {
+		if callerPattern.MatchString(f) {
+			pl[first(km.Caller, "caller")] = jsonQuote(f)
+		} else {
+			pl[first(km.Name, "logger")] = jsonQuote(f)
+		}
+	}
+	return newRecord(pl, keys, km), nil
+}
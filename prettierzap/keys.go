@@ -0,0 +1,251 @@
+package prettierzap
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// KeyMapping represents the keys of the core fields of logs, like the keys of zapcore.EncoderConfig.
+// each field can have several candidate keys, the first one a log has is used.
+type KeyMapping struct {
+	Level      []string
+	Time       []string
+	Caller     []string
+	Message    []string
+	Name       []string
+	Stacktrace []string
+}
+
+// DefaultKeyMapping returns the keys of zap's production encoder config.
+func DefaultKeyMapping() KeyMapping {
+	return KeyMapping{
+		Level:      []string{"level"},
+		Time:       []string{"ts"},
+		Caller:     []string{"caller"},
+		Message:    []string{"msg", "message"},
+		Name:       []string{"logger"},
+		Stacktrace: []string{"stacktrace"},
+	}
+}
+
+// defaultKeys is the key mapping of records read without one.
+var defaultKeys = func() *KeyMapping {
+	m := DefaultKeyMapping()
+	return &m
+}()
+
+// orDefault returns the mapping, or the default one if it is nil.
+func (m *KeyMapping) orDefault() *KeyMapping {
+	if m == nil {
+		return defaultKeys
+	}
+	return m
+}
+
+// Override returns the mapping with the fields that are set in o replaced.
+func (m KeyMapping) Override(o KeyMapping) KeyMapping {
+	for _, f := range []struct{ dst, src *[]string }{
+		{&m.Level, &o.Level},
+		{&m.Time, &o.Time},
+		{&m.Caller, &o.Caller},
+		{&m.Message, &o.Message},
+		{&m.Name, &o.Name},
+		{&m.Stacktrace, &o.Stacktrace},
+	} {
+		if len(*f.src) > 0 {
+			*f.dst = *f.src
+		}
+	}
+	return m
+}
+
+// withFallback returns the mapping with the keys of o that it doesn't have appended to each field,
+// so logs using the keys of o are still read.
+func (m KeyMapping) withFallback(o KeyMapping) KeyMapping {
+	for _, f := range []struct{ dst, src *[]string }{
+		{&m.Level, &o.Level},
+		{&m.Time, &o.Time},
+		{&m.Caller, &o.Caller},
+		{&m.Message, &o.Message},
+		{&m.Name, &o.Name},
+		{&m.Stacktrace, &o.Stacktrace},
+	} {
+		keys := append([]string(nil), *f.dst...)
+		for _, k := range *f.src {
+			if !containsKey(keys, k) {
+				keys = append(keys, k)
+			}
+		}
+		*f.dst = keys
+	}
+	return m
+}
+
+func containsKey(keys []string, key string) bool {
+	for _, k := range keys {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// first returns the first key of the list, or def if the list is empty.
+func first(keys []string, def string) string {
+	if len(keys) == 0 {
+		return def
+	}
+	return keys[0]
+}
+
+// isCore reports whether the key is the key of one of the core fields, except the stacktrace.
+func (m KeyMapping) isCore(key string) bool {
+	for _, ks := range [][]string{m.Level, m.Time, m.Caller, m.Message, m.Name} {
+		for _, k := range ks {
+			if k == key {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// stacktraceKey returns the first of the stacktrace keys the meta fields have.
+func (m *KeyMapping) stacktraceKey(meta map[string]string) string {
+	for _, k := range m.Stacktrace {
+		if _, ok := meta[k]; ok {
+			return k
+		}
+	}
+	return ""
+}
+
+// field returns the list of keys of the field with the given name.
+func (m *KeyMapping) field(name string) (*[]string, bool) {
+	switch strings.TrimSuffix(strings.ToLower(name), "key") {
+	case "level":
+		return &m.Level, true
+	case "time", "ts":
+		return &m.Time, true
+	case "caller":
+		return &m.Caller, true
+	case "message", "msg":
+		return &m.Message, true
+	case "name", "logger":
+		return &m.Name, true
+	case "stacktrace":
+		return &m.Stacktrace, true
+	}
+	return nil, false
+}
+
+// ParseKeyMapping parses a key mapping given as `field=key` pairs separated by commas, where field is
+// one of level, time, caller, message, name and stacktrace, e.g. `level=severity,time=time,caller=source`.
+// a field given several times gets several candidate keys.
+func ParseKeyMapping(s string) (KeyMapping, error) {
+	var m KeyMapping
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[1]) == "" {
+			return KeyMapping{}, fmt.Errorf("invalid key mapping %q, expected field=key", pair)
+		}
+		f, ok := m.field(strings.TrimSpace(kv[0]))
+		if !ok {
+			return KeyMapping{}, fmt.Errorf("unknown field %q in key mapping", kv[0])
+		}
+		*f = append(*f, strings.TrimSpace(kv[1]))
+	}
+	return m, nil
+}
+
+// candidate keys of the core fields used by common encoders: zap production and development configs,
+// log/slog, logrus, Elastic Common Schema and GCP structured logging
+var keyCandidates = KeyMapping{
+	Level:      []string{"level", "severity", "lvl", "loglevel", "log.level", "L"},
+	Time:       []string{"ts", "time", "timestamp", "@timestamp", "T"},
+	Caller:     []string{"caller", "source", "src", "C", "logging.googleapis.com/sourceLocation"},
+	Message:    []string{"msg", "message", "M", "@message"},
+	Name:       []string{"logger", "logger_name", "log.logger", "N"},
+	Stacktrace: []string{"stacktrace", "stack", "stack_trace", "S"},
+}
+
+// DetectKeyMapping guesses the keys of the core fields from a sample of lines.
+// for each field, the candidate key present in most of the JSON lines is picked;
+// fields with no candidate in the sample are left unset.
+func DetectKeyMapping(lines [][]byte) KeyMapping {
+	counts := make(map[string]int)
+	for _, l := range lines {
+		scanObject(l, func(key string, _ []byte) {
+			counts[key]++
+		})
+	}
+
+	var m KeyMapping
+	for _, f := range []struct{ dst, candidates *[]string }{
+		{&m.Level, &keyCandidates.Level},
+		{&m.Time, &keyCandidates.Time},
+		{&m.Caller, &keyCandidates.Caller},
+		{&m.Message, &keyCandidates.Message},
+		{&m.Name, &keyCandidates.Name},
+		{&m.Stacktrace, &keyCandidates.Stacktrace},
+	} {
+		best := ""
+		for _, k := range *f.candidates {
+			if counts[k] > counts[best] {
+				best = k
+			}
+		}
+		if best != "" {
+			*f.dst = []string{best}
+		}
+	}
+	return m
+}
+
+// DefaultDetectLines is the number of JSON lines a key detector learns from.
+const DefaultDetectLines = 10
+
+// KeyDetector detects the key mapping from the first JSON lines of a stream as they come,
+// so no line has to wait for the detection to finish.
+type KeyDetector struct {
+	Base  KeyMapping // keys of the fields that aren't detected, and fallbacks of the detected keys
+	Fixed KeyMapping // keys given by the user, never overridden by the detection
+	Lines int        // number of JSON lines to learn from
+
+	mu     sync.Mutex
+	sample [][]byte
+	keys   *KeyMapping // mapping detected so far, shared by the records read with it
+}
+
+// Observe learns from the line while the detector needs more samples, and returns the key mapping
+// to read the line with.
+// it is safe to use from several goroutines, reading several inputs.
+func (d *KeyDetector) Observe(line []byte) *KeyMapping {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.keys == nil {
+		m := d.Base.Override(d.Fixed)
+		d.keys = &m
+	}
+	if len(d.sample) >= d.Lines || scanObject(line, func(string, []byte) {}) != nil {
+		return d.keys
+	}
+	d.sample = append(d.sample, append([]byte(nil), line...))
+	m := DetectKeyMapping(d.sample).withFallback(d.Base).Override(d.Fixed)
+	d.keys = &m
+	return d.keys
+}
+
+// firstKey returns the raw value of the first of the keys the log has.
+func (pl parsedLog) firstKey(keys []string) string {
+	for _, k := range keys {
+		if v, ok := pl[k]; ok {
+			return v
+		}
+	}
+	return ""
+}
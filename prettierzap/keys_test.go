@@ -0,0 +1,166 @@
+package prettierzap
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestParseKeyMapping(t *testing.T) {
+	type checkFunc func(KeyMapping, error) error
+	check := func(fns ...checkFunc) []checkFunc { return fns }
+
+	hasMapping := func(want KeyMapping) checkFunc {
+		return func(m KeyMapping, _ error) error {
+			if !reflect.DeepEqual(m, want) {
+				return fmt.Errorf("checkMapping: expected %+v received: %+v", want, m)
+			}
+			return nil
+		}
+	}
+	hasError := func(want bool) checkFunc {
+		return func(_ KeyMapping, err error) error {
+			if (err != nil) != want {
+				return fmt.Errorf("checkError: expected error %v received: %v", want, err)
+			}
+			return nil
+		}
+	}
+
+	testScenarios := map[string]struct {
+		input       string
+		checkResult []checkFunc
+	}{
+		"fields": {
+			`level=severity,time=time,message=message,caller=source`,
+			check(hasError(false), hasMapping(KeyMapping{
+				Level:   []string{"severity"},
+				Time:    []string{"time"},
+				Caller:  []string{"source"},
+				Message: []string{"message"},
+			})),
+		},
+		"encoder config names": {
+			`levelKey=lvl,nameKey=logger_name,stacktraceKey=stack`,
+			check(hasError(false), hasMapping(KeyMapping{
+				Level:      []string{"lvl"},
+				Name:       []string{"logger_name"},
+				Stacktrace: []string{"stack"},
+			})),
+		},
+		"several candidates": {
+			`time=time,ts=ts`,
+			check(hasError(false), hasMapping(KeyMapping{Time: []string{"time", "ts"}})),
+		},
+		"unknown field": {
+			`color=red`,
+			check(hasError(true)),
+		},
+		"missing key": {
+			`level=`,
+			check(hasError(true)),
+		},
+	}
+
+	for name, ts := range testScenarios {
+		t.Run(name, func(t *testing.T) {
+			m, err := ParseKeyMapping(ts.input)
+			for _, c := range ts.checkResult {
+				if e := c(m, err); e != nil {
+					t.Error(e)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectKeyMapping(t *testing.T) {
+	lines := [][]byte{
+		[]byte(`{"severity":"INFO","time":"2018-03-30T16:09:05Z","message":"hi","source":"main.go:12"}`),
+		[]byte(`not a json line`),
+		[]byte(`{"severity":"ERROR","time":"2018-03-30T16:09:06Z","message":"oops","level":"custom","stack":"main.main"}`),
+	}
+
+	m := DetectKeyMapping(lines)
+	want := KeyMapping{
+		Level:      []string{"severity"},
+		Time:       []string{"time"},
+		Caller:     []string{"source"},
+		Message:    []string{"message"},
+		Stacktrace: []string{"stack"},
+	}
+	if !reflect.DeepEqual(m, want) {
+		t.Errorf("checkDetect: expected %+v received: %+v", want, m)
+	}
+}
+
+func TestKeyMapping(t *testing.T) {
+	d := KeyDetector{
+		Base:  DefaultKeyMapping(),
+		Fixed: KeyMapping{Caller: []string{"where"}},
+		Lines: DefaultDetectLines,
+	}
+	line := []byte(`{"severity":"warn","time":"2018-03-30T16:09:05Z","message":"hi","source":"main.go:12","where":"svc","user":"test"}`)
+	km := d.Observe(line)
+
+	pj, _ := parseLine(keyedParser(parseJSON), line, km)
+	if pj.GetLevel() != `"warn"` {
+		t.Errorf("GetLevel: expected warn received: %v", pj.GetLevel())
+	}
+	if pj.GetTimestamp() != `"2018-03-30T16:09:05Z"` {
+		t.Errorf("GetTimestamp: expected the time key received: %v", pj.GetTimestamp())
+	}
+	if pj.GetMsg() != `"hi"` {
+		t.Errorf("GetMsg: expected the message key received: %v", pj.GetMsg())
+	}
+	if pj.GetCaller() != `"svc"` {
+		t.Errorf("GetCaller: expected the fixed key received: %v", pj.GetCaller())
+	}
+
+	meta := pj.GetMeta()
+	for _, k := range []string{"severity", "time", "message", "where"} {
+		if _, ok := meta[k]; ok {
+			t.Errorf("GetMeta: expected %v not to be a meta field", k)
+		}
+	}
+	for _, k := range []string{"source", "user"} {
+		if _, ok := meta[k]; !ok {
+			t.Errorf("GetMeta: expected %v to be a meta field", k)
+		}
+	}
+
+	if !filterJSON(pj, LogFilter{MinLevel: "warn"}) {
+		t.Errorf("filterJSON: expected the level to be read from the severity key")
+	}
+
+	fallback, _ := parseLine(keyedParser(parseJSON), []byte("plain text"), km)
+	if fallback.GetMsg() != "plain text" {
+		t.Errorf("GetMsg: expected the line as the message of a non-JSON line received: %v", fallback.GetMsg())
+	}
+}
+
+func TestRecordKeys(t *testing.T) {
+	d := KeyDetector{Base: DefaultKeyMapping(), Lines: 1}
+	line := `{"level":"info","ts":1522426146,"msg":"b","severity":"warn"}`
+	lines := linesOf([]string{`{"severity":"error","time":"2018-03-30T16:09:05Z","message":"a"}`, line})
+	r := NewLogReader(&lines, keyedParser(parseAuto))
+	r.Detector = &d
+
+	r.Next()
+	detected, _ := r.Next()
+
+	// records keep the mapping they were read with
+	pj, _ := parseLine(keyedParser(parseJSON), []byte(line), defaultKeys)
+	if detected.GetLevel() != `"warn"` || pj.GetLevel() != `"info"` {
+		t.Errorf("checkRecordKeys: expected each record to be read with its mapping received: %v and %v", detected.GetLevel(), pj.GetLevel())
+	}
+	if _, ok := pj.GetMeta()["severity"]; !ok {
+		t.Errorf("GetMeta: expected severity to be a meta field of the record read without it")
+	}
+
+	r = NewLogReader(&linesOf{`{"lvl":"error","msg":"c"}`}, keyedParser(parseAuto))
+	r.Keys = &KeyMapping{Level: []string{"lvl"}, Message: []string{"msg"}}
+	if pj, _ := r.Next(); pj.GetLevel() != `"error"` {
+		t.Errorf("checkReaderKeys: expected the level of the keys of the reader received: %v", pj.GetLevel())
+	}
+}
@@ -0,0 +1,151 @@
+package prettierzap
+
+import (
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Severity represents the ordered severity of a log level, following zap's numbering.
+type Severity int
+
+// severities of the zap levels
+const (
+	DebugSeverity Severity = iota - 1
+	InfoSeverity
+	WarnSeverity
+	ErrorSeverity
+	DPanicSeverity
+	PanicSeverity
+	FatalSeverity
+)
+
+var (
+	levelsMu sync.RWMutex
+	levels   = map[string]Severity{
+		debugLevel:   DebugSeverity,
+		infoLevel:    InfoSeverity,
+		warningLevel: WarnSeverity,
+		errorLevel:   ErrorSeverity,
+		dPanicLevel:  DPanicSeverity,
+		panicLevel:   PanicSeverity,
+		fatalLevel:   FatalSeverity,
+		"warning":    WarnSeverity,
+		"trace":      DebugSeverity - 1, // below debug, used by logrus
+	}
+)
+
+// RegisterLevel adds a custom level name with the given severity, so logs of encoders with
+// non-standard level names can be filtered and colored like the zap levels.
+// level names are case-insensitive.
+func RegisterLevel(name string, s Severity) {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	levels[strings.ToLower(name)] = s
+}
+
+// ParseSeverity returns the severity of a level name, or of a number given as a severity.
+func ParseSeverity(name string) (Severity, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+
+	levelsMu.RLock()
+	s, ok := levels[name]
+	levelsMu.RUnlock()
+	if ok {
+		return s, true
+	}
+
+	n, err := strconv.Atoi(name)
+	if err != nil {
+		return 0, false
+	}
+	return Severity(n), true
+}
+
+// ParseLevelNames registers custom level names given as `name=level` pairs separated by commas,
+// where level is either a known level name or a severity number, e.g. `notice=info,crit=fatal,trace=-2`.
+func ParseLevelNames(s string) error {
+	for _, pair := range strings.Split(s, ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || strings.TrimSpace(kv[0]) == "" {
+			return &LevelError{Level: pair}
+		}
+		sev, ok := ParseSeverity(kv[1])
+		if !ok {
+			return &LevelError{Level: kv[1]}
+		}
+		RegisterLevel(strings.TrimSpace(kv[0]), sev)
+	}
+	return nil
+}
+
+// LevelError represents an unknown level name.
+type LevelError struct {
+	Level string
+}
+
+// Error returns the description of the error.
+func (e *LevelError) Error() string {
+	return "unknown level: " + strconv.Quote(e.Level)
+}
+
+// severityOf returns the severity of the level of the log.
+func severityOf(pj ParsedJSON) (Severity, bool) {
+	return ParseSeverity(levelOf(pj))
+}
+
+// levelInSet reports whether the level is one of the comma separated levels of the set.
+// levels are compared case-insensitively and by severity, so aliases of a level match it too.
+func levelInSet(level, set string) bool {
+	sev, known := ParseSeverity(level)
+	for len(set) > 0 {
+		name := set
+		if i := strings.IndexByte(set, ','); i >= 0 {
+			name, set = set[:i], set[i+1:]
+		} else {
+			set = ""
+		}
+
+		name = strings.TrimSpace(name)
+		if strings.EqualFold(name, level) {
+			return true
+		}
+		if s, ok := ParseSeverity(name); ok && known && s == sev {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLevel reports whether the level of the log passes the level filters.
+func filterLevel(pj ParsedJSON, f LogFilter) bool {
+	if f.Level == "" && f.MinLevel == "" && f.MaxLevel == "" {
+		return true
+	}
+	return levelPasses(levelOf(pj), f)
+}
+
+// levelPasses reports whether the decoded level passes the level filters.
+func levelPasses(l string, f LogFilter) bool {
+	if f.Level != "" && !levelInSet(l, f.Level) {
+		return false
+	}
+
+	if f.MinLevel == "" && f.MaxLevel == "" {
+		return true
+	}
+	sev, ok := ParseSeverity(l)
+	if !ok {
+		return false
+	}
+	if min, ok := ParseSeverity(f.MinLevel); f.MinLevel != "" && ok && sev < min {
+		return false
+	}
+	if max, ok := ParseSeverity(f.MaxLevel); f.MaxLevel != "" && ok && sev > max {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package prettierzap
+
+import (
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		Level  string
+		Wanted Severity
+		OK     bool
+	}{
+		{"pass - zap level", "warn", WarnSeverity, true},
+		{"pass - capital level", "ERROR", ErrorSeverity, true},
+		{"pass - alias", "warning", WarnSeverity, true},
+		{"pass - severity number", "-2", Severity(-2), true},
+		{"fails - unknown level", "verbose", 0, false},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			s, ok := ParseSeverity(tc.Level)
+			if ok != tc.OK || s != tc.Wanted {
+				t.Errorf("ParseSeverity(%q): expected (%v, %v) received: (%v, %v)", tc.Level, tc.Wanted, tc.OK, s, ok)
+			}
+		})
+	}
+}
+
+func TestParseLevelNames(t *testing.T) {
+	defer restoreLevels()()
+
+	if err := ParseLevelNames("notice=info, crit=fatal,trace=-2"); err != nil {
+		t.Fatalf("ParseLevelNames: expected no error received: %v", err)
+	}
+
+	for name, wanted := range map[string]Severity{"notice": InfoSeverity, "CRIT": FatalSeverity, "trace": Severity(-2)} {
+		if s, ok := ParseSeverity(name); !ok || s != wanted {
+			t.Errorf("ParseSeverity(%q): expected %v received: (%v, %v)", name, wanted, s, ok)
+		}
+	}
+
+	for _, bad := range []string{"loud=louder", "noequals", "=info"} {
+		if err := ParseLevelNames(bad); err == nil {
+			t.Errorf("ParseLevelNames(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestFilterLevel(t *testing.T) {
+	defer restoreLevels()()
+	RegisterLevel("severe", ErrorSeverity)
+
+	logs := []ParsedJSON{
+		parsedLog{"level": `"debug"`},
+		parsedLog{"level": `"info"`},
+		parsedLog{"level": `"WARN"`},
+		parsedLog{"level": `"error"`},
+		parsedLog{"level": `"fatal"`},
+		parsedLog{"level": `"severe"`},
+		parsedLog{"level": `"custom"`},
+	}
+
+	testScenarios := []struct {
+		Name   string
+		Filter LogFilter
+		Wanted []bool
+	}{
+		{"pass - no level filter", LogFilter{}, []bool{true, true, true, true, true, true, true}},
+		{"pass - exact level", LogFilter{Level: "warn"}, []bool{false, false, true, false, false, false, false}},
+		{"pass - level set", LogFilter{Level: "error, fatal"}, []bool{false, false, false, true, true, true, false}},
+		{"pass - unknown level by name", LogFilter{Level: "custom"}, []bool{false, false, false, false, false, false, true}},
+		{"pass - min level", LogFilter{MinLevel: "warn"}, []bool{false, false, true, true, true, true, false}},
+		{"pass - max level", LogFilter{MaxLevel: "info"}, []bool{true, true, false, false, false, false, false}},
+		{"pass - level range", LogFilter{MinLevel: "info", MaxLevel: "error"}, []bool{false, true, true, true, false, true, false}},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			for i, pj := range logs {
+				if got := filterLevel(pj, tc.Filter); got != tc.Wanted[i] {
+					t.Errorf("filterLevel: expected @(%d) %v received: %v", i, tc.Wanted[i], got)
+				}
+			}
+		})
+	}
+}
+
+// restoreLevels returns a function restoring the registered levels as they are now.
+func restoreLevels() func() {
+	levelsMu.Lock()
+	defer levelsMu.Unlock()
+	saved := make(map[string]Severity, len(levels))
+	for name, s := range levels {
+		saved[name] = s
+	}
+	return func() {
+		levelsMu.Lock()
+		levels = saved
+		levelsMu.Unlock()
+	}
+}
@@ -0,0 +1,122 @@
+package prettierzap
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+var errNotLogfmt = errors.New("not a logfmt line")
+
+// parseLogfmt parses a line of `key=value` pairs like `level=info msg="hello world" status=200`.
+func parseLogfmt(line []byte, km *KeyMapping) (ParsedJSON, error) {
+	pl, keys, err := scanOrderedLogfmt(line)
+	if err != nil {
+		return nil, err
+	}
+	return newRecord(normalizeKeys(pl, km), keys, km), nil
+}
+
+// scanLogfmt reads the pairs of a logfmt line as raw JSON values.
+// quoted values are strings, bare values are numbers, booleans or null when they read as such
+// and strings otherwise, keys without a value are true.
+func scanLogfmt(line []byte) (parsedLog, error) {
+	pl, _, err := scanOrderedLogfmt(line)
+	return pl, err
+}
+
+// scanOrderedLogfmt reads the pairs of a logfmt line like scanLogfmt, and the keys in their order.
+func scanOrderedLogfmt(line []byte) (parsedLog, []string, error) {
+	s := strings.TrimSpace(string(line))
+	if s == "" || !utf8.ValidString(s) {
+		return nil, nil, errNotLogfmt
+	}
+
+	pl := parsedLog{}
+	var keys []string
+	set := func(key, raw string) {
+		if _, ok := pl[key]; !ok {
+			keys = append(keys, key)
+		}
+		pl[key] = raw
+	}
+	pairs := 0
+	for len(s) > 0 {
+		i := strings.IndexAny(s, "= \t\"")
+		if i == 0 {
+			return nil, nil, errNotLogfmt
+		}
+		if i < 0 {
+			i = len(s)
+		}
+		key := s[:i]
+		s = s[i:]
+
+		if !strings.HasPrefix(s, "=") {
+			if strings.HasPrefix(s, "\"") {
+				return nil, nil, errNotLogfmt
+			}
+			set(key, "true")
+			s = strings.TrimLeft(s, " \t")
+			continue
+		}
+		s = s[1:]
+
+		var raw string
+		if strings.HasPrefix(s, "\"") {
+			end := closingQuote(s)
+			if end < 0 {
+				return nil, nil, errNotLogfmt
+			}
+			v, err := strconv.Unquote(s[:end+1])
+			if err != nil {
+				return nil, nil, errNotLogfmt
+			}
+			raw, s = jsonQuote(v), s[end+1:]
+		} else {
+			end := strings.IndexAny(s, " \t")
+			if end < 0 {
+				end = len(s)
+			}
+			raw, s = bareValue(s[:end]), s[end:]
+		}
+		if s != "" && s[0] != ' ' && s[0] != '\t' {
+			return nil, nil, errNotLogfmt
+		}
+
+		set(key, raw)
+		pairs++
+		s = strings.TrimLeft(s, " \t")
+	}
+
+	if pairs == 0 {
+		return nil, nil, errNotLogfmt
+	}
+	return pl, keys, nil
+}
+
+// closingQuote returns the index of the quote closing the quoted string s starts with.
+func closingQuote(s string) int {
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
+
+// bareValue returns the raw JSON value of an unquoted logfmt value.
+func bareValue(v string) string {
+	switch v {
+	case "true", "false", "null":
+		return v
+	}
+	if val, err := ParseValue([]byte(v)); err == nil && val.Kind() == NumberKind {
+		return v
+	}
+	return jsonQuote(v)
+}
@@ -0,0 +1,41 @@
+package prettierzap
+
+import (
+	"testing"
+)
+
+func TestScanLogfmt(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		Line   string
+		Wanted parsedLog
+	}{
+		{"pass - pairs", `level=info msg=hi`, parsedLog{"level": `"info"`, "msg": `"hi"`}},
+		{"pass - quoted", `msg="a \"b\"\tc" empty=`, parsedLog{"msg": `"a \"b\"\tc"`, "empty": `""`}},
+		{"pass - literals", `n=-1.5 ok=false none=null flag`, parsedLog{"n": "-1.5", "ok": "false", "none": "null", "flag": "true"}},
+		{"fails - no pairs", `just words`, nil},
+		{"fails - unterminated quote", `msg="oops`, nil},
+		{"fails - missing key", `=value`, nil},
+		{"fails - text after quote", `msg="a"b`, nil},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			pl, err := scanLogfmt([]byte(tc.Line))
+			if tc.Wanted == nil {
+				if err == nil {
+					t.Errorf("scanLogfmt: expected an error received: %v", pl)
+				}
+				return
+			}
+			if err != nil || len(pl) != len(tc.Wanted) {
+				t.Fatalf("scanLogfmt: expected %v received: %v (%v)", tc.Wanted, pl, err)
+			}
+			for k, v := range tc.Wanted {
+				if pl[k] != v {
+					t.Errorf("scanLogfmt: expected %v: %v received: %v", k, v, pl[k])
+				}
+			}
+		})
+	}
+}
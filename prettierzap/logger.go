@@ -0,0 +1,73 @@
+package prettierzap
+
+import (
+	"hash/fnv"
+	"path"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// colors given to logger names, none of them is used for levels or messages
+var loggerColors = []func(string, ...interface{}) string{
+	color.New(color.FgGreen).SprintfFunc(),
+	color.New(color.FgBlue).SprintfFunc(),
+	color.New(color.FgMagenta).SprintfFunc(),
+	color.New(color.FgHiGreen).SprintfFunc(),
+	color.New(color.FgHiBlue).SprintfFunc(),
+	color.New(color.FgHiMagenta).SprintfFunc(),
+	color.New(color.FgHiCyan).SprintfFunc(),
+	color.New(color.FgGreen, color.Bold).SprintfFunc(),
+	color.New(color.FgBlue, color.Bold).SprintfFunc(),
+	color.New(color.FgMagenta, color.Bold).SprintfFunc(),
+}
+
+// loggerColor returns the color of a logger name.
+// the color only depends on the name, so a logger keeps its color across lines and runs.
+func loggerColor(name string) func(string, ...interface{}) string {
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return loggerColors[h.Sum32()%uint32(len(loggerColors))]
+}
+
+// nameOf returns the decoded logger name of the log.
+func nameOf(pj ParsedJSON) string {
+	return valueOf(pj.GetName()).String()
+}
+
+// ParseLoggerPatterns splits comma separated glob patterns of logger names like `api.*,db`.
+// the patterns use the syntax of path.Match.
+func ParseLoggerPatterns(s string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// matchLogger reports whether the logger name matches any of the glob patterns.
+func matchLogger(name string, patterns []string) bool {
+	for _, p := range patterns {
+		if ok, _ := path.Match(p, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// filterLogger reports whether the logger of the log passes the logger filter.
+// logs without a logger name only pass when there is no filter.
+func filterLogger(pj ParsedJSON, f LogFilter) bool {
+	if len(f.Logger) == 0 {
+		return true
+	}
+	name := nameOf(pj)
+	return name != "" && matchLogger(name, f.Logger)
+}
@@ -0,0 +1,69 @@
+package prettierzap
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseLoggerPatterns(t *testing.T) {
+	patterns, err := ParseLoggerPatterns("api.*, db,,")
+	if err != nil {
+		t.Fatalf("ParseLoggerPatterns: expected no error received: %v", err)
+	}
+	if len(patterns) != 2 || patterns[0] != "api.*" || patterns[1] != "db" {
+		t.Errorf("ParseLoggerPatterns: expected [api.* db] received: %v", patterns)
+	}
+
+	if _, err := ParseLoggerPatterns("api.[*"); err == nil {
+		t.Errorf("ParseLoggerPatterns: expected an error for a malformed pattern")
+	}
+}
+
+func TestFilterLogger(t *testing.T) {
+	testScenarios := []struct {
+		Name    string
+		Log     parsedLog
+		Loggers []string
+		Wanted  bool
+	}{
+		{"pass - no filter", parsedLog{"msg": `"hi"`}, nil, true},
+		{"pass - exact name", parsedLog{"logger": `"db"`}, []string{"db"}, true},
+		{"pass - glob", parsedLog{"logger": `"api.http"`}, []string{"db", "api.*"}, true},
+		{"pass - nested name", parsedLog{"logger": `"api.http.auth"`}, []string{"api.*"}, true},
+		{"fails - other logger", parsedLog{"logger": `"db.pool"`}, []string{"api.*"}, false},
+		{"fails - no logger", parsedLog{"msg": `"hi"`}, []string{"*"}, false},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			if ok := filterJSON(tc.Log, LogFilter{Logger: tc.Loggers}); ok != tc.Wanted {
+				t.Errorf("filterJSON: expected %v received: %v", tc.Wanted, ok)
+			}
+		})
+	}
+}
+
+func TestLoggerName(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	pj, _ := ParseJSONByteArray([]byte(`{"level":"info","ts":1522426145,"logger":"api.http","msg":"hi","user":"test"}`))
+	if pj.GetName() != `"api.http"` {
+		t.Errorf("GetName: expected the logger field received: %v", pj.GetName())
+	}
+	if _, ok := pj.GetMeta()["logger"]; ok {
+		t.Errorf("GetMeta: expected the logger not to be a meta field")
+	}
+
+	s, _ := GenerateOutputString(pj, false)
+	if !strings.Contains(strings.SplitN(s, "\n", 2)[0], "<api.http>") {
+		t.Errorf("GenerateOutputString: expected the logger on the log line received: %v", s)
+	}
+
+	if loggerColor("api.http")("x") != loggerColor("api.http")("x") {
+		t.Errorf("loggerColor: expected the same color for the same logger")
+	}
+}
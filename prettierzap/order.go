@@ -0,0 +1,124 @@
+package prettierzap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// orders of the meta fields of the output
+const (
+	MetaOrderOriginal = "original" // the order of the fields in the lines, the default
+	MetaOrderSorted   = "sorted"   // the alphabetical order of the keys
+)
+
+// ParseMetaOrder returns the order of the meta fields with the given name.
+func ParseMetaOrder(name string) (string, error) {
+	switch n := strings.ToLower(strings.TrimSpace(name)); n {
+	case "":
+		return MetaOrderOriginal, nil
+	case MetaOrderOriginal, MetaOrderSorted:
+		return n, nil
+	}
+	return "", fmt.Errorf("unknown meta order %q", name)
+}
+
+// logRecord represents a log read by the built-in parsers: its fields, the order of their keys in the line
+// it was read from, and the key mapping its core fields are read with.
+type logRecord struct {
+	parsedLog
+	keys []string
+	km   *KeyMapping
+	at   time.Time // time of records pz wrote the timestamp of, like lines of plain text, read as it is
+}
+
+// newRecord returns the record of the fields, read with the key mapping.
+func newRecord(pl parsedLog, keys []string, km *KeyMapping) logRecord {
+	return logRecord{parsedLog: pl, keys: keys, km: km.orDefault()}
+}
+
+// scanLog reads the members of a JSON object into a record read with the key mapping, keeping the order of their keys.
+// the members before an error are read too, like the ones of a truncated line.
+func scanLog(line []byte, km *KeyMapping) (logRecord, error) {
+	lr := newRecord(parsedLog{}, make([]string, 0, 8), km)
+	err := scanObject(line, func(key string, value []byte) {
+		if _, ok := lr.parsedLog[key]; !ok {
+			lr.keys = append(lr.keys, key)
+		}
+		lr.parsedLog[key] = string(value)
+	})
+	return lr, err
+}
+
+// withFields returns the record with the fields of pl, read from the same line, in the order of the record.
+func (lr logRecord) withFields(pl parsedLog) logRecord {
+	lr.parsedLog = pl
+	return lr
+}
+
+// record returns the record, for the types embedding it.
+func (lr logRecord) record() logRecord {
+	return lr
+}
+
+// asParsedLog returns the fields of a log read by the built-in parsers.
+func asParsedLog(pj ParsedJSON) (parsedLog, bool) {
+	switch l := pj.(type) {
+	case parsedLog:
+		return l, true
+	case logRecord:
+		return l.parsedLog, true
+	}
+	return nil, false
+}
+
+// recordOf returns the record of a log read by the built-in parsers, without its labels.
+func recordOf(pj ParsedJSON) (logRecord, bool) {
+	if r, ok := unwrapLog(pj).(interface{ record() logRecord }); ok {
+		return r.record(), true
+	}
+	return logRecord{}, false
+}
+
+// orderOf returns the keys of the log in the order of its line, if it is known.
+func orderOf(pj ParsedJSON) []string {
+	lr, _ := recordOf(pj)
+	return lr.keys
+}
+
+// mappingOf returns the key mapping the core fields of the log are read with.
+func mappingOf(pj ParsedJSON) *KeyMapping {
+	lr, _ := recordOf(pj)
+	return lr.km.orDefault()
+}
+
+// metaKeys returns the keys of the meta fields in the order of the printer: the pinned keys first,
+// then the others in the order of the line or sorted. keys missing from the line, like the ones added
+// to records by pz, come after the ones of the line, sorted.
+func (p *Printer) metaKeys(pj ParsedJSON, meta map[string]string) []string {
+	keys := make([]string, 0, len(meta))
+	seen := make(map[string]bool, len(meta))
+	add := func(k string) {
+		if _, ok := meta[k]; ok && !seen[k] {
+			seen[k] = true
+			keys = append(keys, k)
+		}
+	}
+
+	for _, k := range p.PinnedKeys {
+		add(k)
+	}
+	if p.MetaOrder != MetaOrderSorted {
+		for _, k := range orderOf(pj) {
+			add(k)
+		}
+	}
+
+	rest := len(keys)
+	for k := range meta {
+		add(k)
+	}
+	sort.Strings(keys[rest:])
+	return keys
+}
@@ -0,0 +1,56 @@
+package prettierzap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestScanLog(t *testing.T) {
+	ol, err := scanLog([]byte(`{"z":1,"b":2,"z":3,"a":{"y":1}}`), nil)
+	if err != nil {
+		t.Fatalf("scanLog: expected no error received: %v", err)
+	}
+	if want := []string{"z", "b", "a"}; !reflect.DeepEqual(ol.keys, want) {
+		t.Errorf("checkKeys: expected %v received: %v", want, ol.keys)
+	}
+	if ol.parsedLog["z"] != "3" {
+		t.Errorf("checkDuplicate: expected the last value received: %s", ol.parsedLog["z"])
+	}
+}
+
+func TestMetaKeys(t *testing.T) {
+	pj, _ := ParseJSONByteArray([]byte(`{"level":"info","msg":"a","z":1,"request_id":"r","b":2,"user_id":3}`))
+	meta := pj.GetMeta()
+	meta["added"] = `"x"`
+
+	testScenarios := []struct {
+		Name   string
+		Order  string
+		Pinned []string
+		Wanted []string
+	}{
+		{"pass - original order", MetaOrderOriginal, nil, []string{"z", "request_id", "b", "user_id", "added"}},
+		{"pass - sorted", MetaOrderSorted, nil, []string{"added", "b", "request_id", "user_id", "z"}},
+		{"pass - pinned keys first", MetaOrderOriginal, []string{"user_id", "missing", "request_id"}, []string{"user_id", "request_id", "z", "b", "added"}},
+		{"pass - pinned keys and sorted", MetaOrderSorted, []string{"user_id"}, []string{"user_id", "added", "b", "request_id", "z"}},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := NewPrinter()
+			p.MetaOrder = tc.Order
+			p.PinnedKeys = tc.Pinned
+			if got := p.metaKeys(pj, meta); !reflect.DeepEqual(got, tc.Wanted) {
+				t.Errorf("metaKeys: expected %v received: %v", tc.Wanted, got)
+			}
+		})
+	}
+
+	if got := NewPrinter().metaKeys(parsedLog{"b": "1", "a": "2"}, map[string]string{"b": "1", "a": "2"}); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("checkUnordered: expected sorted keys received: %v", got)
+	}
+
+	if _, err := ParseMetaOrder("random"); err == nil {
+		t.Errorf("ParseMetaOrder: expected an error for an unknown order")
+	}
+}
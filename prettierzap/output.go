@@ -0,0 +1,271 @@
+package prettierzap
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// names of the output formats
+const (
+	OutputPretty           = "pretty"            // colored text for humans, the default
+	OutputJSON             = "json"              // the original lines of the records, as they were read
+	OutputNDJSONNormalized = "ndjson-normalized" // one JSON object per record with zap's keys and decoded timestamps
+	OutputLogfmt           = "logfmt"            // key=value pairs with zap's keys and decoded values
+	OutputCSV              = "csv"               // comma separated columns, with a header
+	OutputTSV              = "tsv"               // tab separated columns, with a header
+)
+
+// DefaultColumns are the fields written by the csv and tsv outputs.
+var DefaultColumns = []string{"ts", "level", "logger", "caller", "msg"}
+
+// ParseOutput returns the output format with the given name.
+func ParseOutput(name string) (string, error) {
+	switch n := strings.ToLower(strings.TrimSpace(name)); n {
+	case "", OutputPretty:
+		return OutputPretty, nil
+	case OutputJSON, OutputNDJSONNormalized, OutputLogfmt, OutputCSV, OutputTSV:
+		return n, nil
+	case "ndjson":
+		return OutputNDJSONNormalized, nil
+	}
+	return "", fmt.Errorf("unknown output format %q", name)
+}
+
+// ParseColumns splits a comma separated list of field paths like `ts,level,msg,http.status`.
+func ParseColumns(s string) []string {
+	var columns []string
+	for _, c := range strings.Split(s, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			columns = append(columns, c)
+		}
+	}
+	return columns
+}
+
+// render renders the record in the output format of the printer.
+func (p *Printer) render(pj ParsedJSON) (string, error) {
+	switch p.Output {
+	case OutputJSON:
+		return p.renderJSON(pj), nil
+	case OutputNDJSONNormalized:
+		return p.renderNormalized(pj), nil
+	case OutputLogfmt:
+		return p.renderLogfmt(pj), nil
+	case OutputCSV:
+		return renderCSV(p.columnValues(pj))
+	case OutputTSV:
+		return renderTSV(p.columnValues(pj)), nil
+	}
+	if p.template != nil {
+		return p.renderTemplate(pj)
+	}
+	return p.GenerateOutputString(pj)
+}
+
+// header returns the first line of the output, written before the records.
+func (p *Printer) header() (string, error) {
+	switch p.Output {
+	case OutputCSV:
+		return renderCSV(p.columns())
+	case OutputTSV:
+		return renderTSV(p.columns()), nil
+	}
+	return "", nil
+}
+
+// renderJSON writes the original line of the record untouched, but for the meta fields the printer hides.
+// records that weren't read from a JSON line, like plain text, panics and records with continuation
+// lines, are written normalized.
+func (p *Printer) renderJSON(pj ParsedJSON) string {
+	line, ok := LineOf(pj)
+	if !ok || strings.IndexByte(line, '\n') >= 0 || scanMembers([]byte(line), checkMember) != nil {
+		return p.renderNormalized(pj)
+	}
+	if len(p.Fields) > 0 || len(p.Hide) > 0 {
+		return p.projectLine(pj, strings.TrimSpace(line)) + "\n"
+	}
+	return strings.TrimSpace(line) + "\n"
+}
+
+// field represents a field of a normalized record.
+type field struct {
+	key   string
+	value Value
+}
+
+// normalizedFields returns the fields of the record with zap's keys, the core ones first and the others
+// in the order of the printer.
+// the core fields are decoded into strings, the timestamp into an RFC3339 time in the time zone
+// of the printer if it can be read.
+func (p *Printer) normalizedFields(pj ParsedJSON) []field {
+	var fields []field
+	add := func(key, raw string, decode func(string) string) {
+		if raw == "" {
+			return
+		}
+		v := valueOf(raw)
+		if decode != nil {
+			v = stringValue(decode(raw))
+		}
+		fields = append(fields, field{key, v})
+	}
+	decoded := func(raw string) string { return valueOf(raw).String() }
+
+	add("ts", pj.GetTimestamp(), func(raw string) string {
+		if t, err := pj.GetTime(); err == nil {
+			return p.isoTime(t)
+		}
+		return decoded(raw)
+	})
+	add("level", pj.GetLevel(), decoded)
+	add("logger", pj.GetName(), decoded)
+	if src := SourceOf(pj); src != "" {
+		add("source", jsonQuote(src), decoded)
+	}
+	add("caller", pj.GetCaller(), decoded)
+	add("msg", pj.GetMsg(), decoded)
+
+	meta := p.meta(pj)
+	if stKey := mappingOf(pj).stacktraceKey(meta); stKey != "" {
+		add("stacktrace", meta[stKey], nil)
+		delete(meta, stKey)
+	}
+
+	for _, k := range p.metaKeys(pj, meta) {
+		add(k, meta[k], nil)
+	}
+	return fields
+}
+
+// isoTime formats the time as an RFC3339 time with nanoseconds in the time zone of the printer.
+func (p *Printer) isoTime(t time.Time) string {
+	return t.In(p.location()).Format(time.RFC3339Nano)
+}
+
+// stringValue returns the value of a string.
+func stringValue(s string) Value {
+	return Value{kind: StringKind, raw: jsonQuote(s), str: s}
+}
+
+// renderNormalized writes the record as a JSON object with zap's keys.
+func (p *Printer) renderNormalized(pj ParsedJSON) string {
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, f := range p.normalizedFields(pj) {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(jsonQuote(f.key))
+		b.WriteByte(':')
+		b.WriteString(jsonText(f.value))
+	}
+	b.WriteString("}\n")
+	return b.String()
+}
+
+// jsonText returns the JSON text of a value.
+func jsonText(v Value) string {
+	if v.kind == StringKind {
+		return jsonQuote(v.str)
+	}
+	return strings.TrimSpace(v.raw)
+}
+
+// renderLogfmt writes the record as logfmt pairs with zap's keys.
+func (p *Printer) renderLogfmt(pj ParsedJSON) string {
+	var b bytes.Buffer
+	for i, f := range p.normalizedFields(pj) {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(f.key)
+		b.WriteByte('=')
+		b.WriteString(logfmtQuote(f.value.String()))
+	}
+	b.WriteByte('\n')
+	return b.String()
+}
+
+// logfmtQuote quotes the value of a logfmt pair if it can't be written bare.
+func logfmtQuote(s string) string {
+	if s == "" {
+		return `""`
+	}
+	for _, r := range s {
+		if r <= ' ' || r == '=' || r == '"' || r == '\\' || !strconv.IsPrint(r) {
+			return strconv.Quote(s)
+		}
+	}
+	return s
+}
+
+// columns returns the fields written by the csv and tsv outputs.
+func (p *Printer) columns() []string {
+	if len(p.Columns) == 0 {
+		return DefaultColumns
+	}
+	return p.Columns
+}
+
+// columnValues returns the text of the columns of the record.
+// columns are field paths, the core fields are found by their zap keys whatever key the log used.
+// the meta fields the printer hides are left empty.
+func (p *Printer) columnValues(pj ParsedJSON) []string {
+	columns := p.columns()
+	values := make([]string, len(columns))
+	meta := pj.GetMeta()
+	shown := meta
+	if len(p.Fields) > 0 || len(p.Hide) > 0 {
+		shown = p.meta(pj)
+	}
+	for i, c := range columns {
+		switch c {
+		case "ts":
+			if t, err := pj.GetTime(); err == nil {
+				values[i] = p.isoTime(t)
+			} else {
+				values[i] = valueOf(pj.GetTimestamp()).String()
+			}
+		case "source":
+			values[i] = SourceOf(pj)
+		case "stacktrace":
+			values[i] = valueOf(shown[mappingOf(pj).stacktraceKey(meta)]).String()
+		default:
+			r := queryRecord{pj}
+			if isMetaPath(meta, c) {
+				r = queryRecord{parsedLog(shown)}
+			}
+			if v, ok := r.value(c); ok {
+				values[i] = v.String()
+			}
+		}
+	}
+	return values
+}
+
+// renderCSV writes the values as a line of comma separated values.
+func renderCSV(values []string) (string, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	if err := w.Write(values); err != nil {
+		return "", err
+	}
+	w.Flush()
+	return b.String(), w.Error()
+}
+
+// tsvEscaper escapes the characters separating the values and lines of tab separated values.
+var tsvEscaper = strings.NewReplacer(`\`, `\\`, "\t", `\t`, "\n", `\n`, "\r", `\r`)
+
+// renderTSV writes the values as a line of tab separated values.
+func renderTSV(values []string) string {
+	escaped := make([]string, len(values))
+	for i, v := range values {
+		escaped[i] = tsvEscaper.Replace(v)
+	}
+	return strings.Join(escaped, "\t") + "\n"
+}
@@ -0,0 +1,96 @@
+package prettierzap
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	line := `{"level":"error", "ts":1522426145.187,"logger":"api","caller":"a/b.go:1","msg":"boom \"x\"","user":{"id":1},"status":500}`
+	pj, _ := ParseJSONByteArray([]byte(line))
+
+	note, _ := ParseJSONByteArray([]byte(`{"level":"info","msg":"a","note":"x, \"y\"\nz"}`))
+
+	testScenarios := []struct {
+		Name    string
+		Output  string
+		Columns []string
+		Hide    []string
+		Log     ParsedJSON
+		Wanted  string
+	}{
+		{"pass - json original line", OutputJSON, nil, nil, withLine(pj, line), line + "\n"},
+		{"pass - json without the line", OutputJSON, nil, nil, pj, `{"ts":"2018-03-30T16:09:05.187Z","level":"error","logger":"api","caller":"a/b.go:1","msg":"boom \"x\"","user":{"id":1},"status":500}` + "\n"},
+		{"pass - json with hidden fields", OutputJSON, nil, []string{"user", "status"}, withLine(pj, line), `{"level":"error", "ts":1522426145.187,"logger":"api","caller":"a/b.go:1","msg":"boom \"x\""}` + "\n"},
+		{"pass - ndjson normalized", OutputNDJSONNormalized, nil, nil, WithSource(pj, "app.log"), `{"ts":"2018-03-30T16:09:05.187Z","level":"error","logger":"api","source":"app.log","caller":"a/b.go:1","msg":"boom \"x\"","user":{"id":1},"status":500}` + "\n"},
+		{"pass - logfmt", OutputLogfmt, nil, nil, pj, `ts=2018-03-30T16:09:05.187Z level=error logger=api caller=a/b.go:1 msg="boom \"x\"" user="{\"id\":1}" status=500` + "\n"},
+		{"pass - csv", OutputCSV, nil, nil, pj, `2018-03-30T16:09:05.187Z,error,api,a/b.go:1,"boom ""x"""` + "\n"},
+		{"pass - csv value with a comma, quotes and a new line", OutputCSV, []string{"msg", "note"}, nil, note, "a,\"x, \"\"y\"\"\nz\"\n"},
+		{"pass - tsv columns", OutputTSV, []string{"msg", "user.id", "missing"}, nil, pj, "boom \"x\"\t1\t\n"},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := NewPrinter()
+			p.Location = time.UTC
+			p.Output = tc.Output
+			p.Columns = tc.Columns
+			p.Hide = tc.Hide
+			got, err := p.render(tc.Log)
+			if err != nil {
+				t.Fatalf("render: expected no error received: %v", err)
+			}
+			if got != tc.Wanted {
+				t.Errorf("render: expected %s received: %s", tc.Wanted, got)
+			}
+		})
+	}
+}
+
+func TestPrintRecordsHeader(t *testing.T) {
+	lines := linesOf([]string{`{"level":"info","ts":1,"msg":"a"}`, `{"level":"warn","ts":2,"msg":"b"}`})
+	r := NewLogReader(&lines, keyedParser(parseAuto))
+
+	p := NewPrinter()
+	p.Output = OutputTSV
+	p.Columns = []string{"level", "msg"}
+	var b bytes.Buffer
+	if err := p.PrintRecords(&b, r.Next, LogFilter{MinLevel: "warn"}, 1); err != nil {
+		t.Fatalf("PrintRecords: expected no error received: %v", err)
+	}
+	if want := "level\tmsg\nwarn\tb\n"; b.String() != want {
+		t.Errorf("checkHeader: expected %q received: %q", want, b.String())
+	}
+}
+
+func TestKeepLines(t *testing.T) {
+	testScenarios := []struct {
+		Name      string
+		Multiline bool
+		Wanted    string
+	}{
+		{"pass - line of the record", false, `{"level":"info", "msg":"a"}`},
+		{"pass - lines of the assembled record", true, `{"level":"info", "msg":"a"}` + "\n  stack of a"},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			lines := linesOf([]string{`{"level":"info", "msg":"a"}`, `  stack of a`})
+			r := NewLogReader(&lines, keyedParser(parseAuto))
+			r.KeepLines = true
+			if tc.Multiline {
+				r.Assembler = NewAssembler(r.Parser)
+				r.Assembler.KeepLines = true
+			}
+
+			pj, err := r.Next()
+			if err != nil {
+				t.Fatalf("Next: expected no error received: %v", err)
+			}
+			if got, _ := LineOf(pj); got != tc.Wanted {
+				t.Errorf("LineOf: expected %q received: %q", tc.Wanted, got)
+			}
+		})
+	}
+}
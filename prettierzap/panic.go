@@ -0,0 +1,250 @@
+package prettierzap
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Frame represents a call of a goroutine stack.
+type Frame struct {
+	Func string // function with its package, like `main.(*Server).serve`
+	File string
+	Line int
+}
+
+// String returns the function and the short location of the frame.
+func (f Frame) String() string {
+	if f.File == "" {
+		return f.Func
+	}
+	return fmt.Sprintf("%s %s:%d", f.Func, shortCaller(f.File), f.Line)
+}
+
+// Goroutine represents a group of goroutines of a dump with identical stacks.
+type Goroutine struct {
+	IDs       []int  // ids of the goroutines, in the order of the dump
+	State     string // state of the first goroutine, like `running` or `chan receive, 5 minutes`
+	Frames    []Frame
+	CreatedBy Frame // function that started the goroutines, if known
+}
+
+// Dump represents a Go panic, runtime error or goroutine dump.
+type Dump struct {
+	Reason     string      // first line of the dump, like `panic: runtime error: index out of range`
+	Goroutines []Goroutine // groups of goroutines, the one that panicked first
+	Lines      []string    // other lines of the dump, like `[signal SIGSEGV …]` or `exit status 2`
+}
+
+// Count returns the number of goroutines of the dump.
+func (d Dump) Count() int {
+	n := 0
+	for _, g := range d.Goroutines {
+		n += len(g.IDs)
+	}
+	return n
+}
+
+var (
+	goroutineHeader = regexp.MustCompile(`^goroutine (\d+) \[([^\]]*)\]:$`)
+	frameLocation   = regexp.MustCompile(`^\s+(.+\.(?:go|s)|\?\?\?):(\d+)(?: \+0x[0-9a-f]+)?$`)
+	createdBy       = regexp.MustCompile(`^created by (\S+)`)
+)
+
+// ParseDump parses the text of a Go panic or goroutine dump, as written by the runtime.
+// goroutines with the same stack are grouped, whatever their states.
+func ParseDump(text string) (Dump, bool) {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) == 0 || !panicStart.MatchString(lines[0]) {
+		return Dump{}, false
+	}
+
+	d := Dump{Reason: strings.TrimSpace(lines[0])}
+	groups := make(map[string]int)
+
+	var g *Goroutine
+	add := func() {
+		if g == nil {
+			return
+		}
+		sig := stackSignature(*g)
+		if i, ok := groups[sig]; ok {
+			d.Goroutines[i].IDs = append(d.Goroutines[i].IDs, g.IDs...)
+		} else {
+			groups[sig] = len(d.Goroutines)
+			d.Goroutines = append(d.Goroutines, *g)
+		}
+		g = nil
+	}
+
+	for i := 1; i < len(lines); i++ {
+		l := strings.TrimRight(lines[i], "\r")
+		if m := goroutineHeader.FindStringSubmatch(l); m != nil {
+			add()
+			id, _ := strconv.Atoi(m[1])
+			g = &Goroutine{IDs: []int{id}, State: m[2]}
+			continue
+		}
+		if strings.TrimSpace(l) == "" {
+			continue
+		}
+
+		// every call is followed by its location, other lines like `exit status 2` aren't part of the stack
+		var loc []string
+		if g != nil && i+1 < len(lines) {
+			loc = frameLocation.FindStringSubmatch(lines[i+1])
+		}
+		if loc == nil {
+			d.Lines = append(d.Lines, strings.TrimSpace(l))
+			continue
+		}
+		i++
+
+		f := Frame{Func: trimArgs(l)}
+		if m := createdBy.FindStringSubmatch(l); m != nil {
+			f.Func = m[1]
+		}
+		f.File = loc[1]
+		f.Line, _ = strconv.Atoi(loc[2])
+
+		if strings.HasPrefix(l, "created by ") {
+			g.CreatedBy = f
+		} else {
+			g.Frames = append(g.Frames, f)
+		}
+	}
+	add()
+	return d, true
+}
+
+// trimArgs removes the arguments of a function line of a stack, like `main.f(0x1, 0x2)`.
+func trimArgs(l string) string {
+	l = strings.TrimSpace(l)
+	if strings.HasSuffix(l, ")") {
+		if i := strings.LastIndexByte(l, '('); i > 0 {
+			return l[:i]
+		}
+	}
+	return l
+}
+
+// stackSignature returns a key identical for goroutines with identical stacks.
+func stackSignature(g Goroutine) string {
+	var b strings.Builder
+	for _, f := range g.Frames {
+		b.WriteString(f.String())
+		b.WriteByte('\n')
+	}
+	b.WriteString(g.CreatedBy.String())
+	return b.String()
+}
+
+// panicLog represents the record of a Go panic or goroutine dump.
+// it is a fatal log, with the reason of the dump as message and the raw dump as stacktrace.
+type panicLog struct {
+	logRecord
+	dump Dump
+}
+
+// Dump returns the parsed goroutines of the record.
+func (pl panicLog) Dump() Dump {
+	return pl.dump
+}
+
+// dumper represents records carrying a goroutine dump.
+type dumper interface {
+	Dump() Dump
+}
+
+// newPanicLog creates the record of a dump from its first line and the rest of its lines.
+func newPanicLog(head string, lines []string, km *KeyMapping) (ParsedJSON, bool) {
+	d, ok := ParseDump(head + "\n" + strings.Join(lines, "\n"))
+	if !ok {
+		return nil, false
+	}
+
+	pj, _ := parseText([]byte(head), km)
+	lr := pj.(logRecord)
+	pl := lr.parsedLog
+	pl[first(km.Level, "level")] = jsonQuote(fatalLevel)
+	pl[first(km.Message, "msg")] = jsonQuote(d.Reason)
+	pl["goroutines"] = strconv.Itoa(d.Count())
+	if len(d.Goroutines) > 0 {
+		if f, ok := topFrame(d.Goroutines[0]); ok {
+			pl[first(km.Caller, "caller")] = jsonQuote(fmt.Sprintf("%s:%d", shortCaller(f.File), f.Line))
+		}
+	}
+	attachLines(pl, lines, lr.km)
+	return panicLog{lr, d}, true
+}
+
+// topFrame returns the first frame of the goroutine out of the runtime, where the panic was raised.
+func topFrame(g Goroutine) (Frame, bool) {
+	for _, f := range g.Frames {
+		if f.File != "" && f.Func != "panic" && !strings.HasPrefix(f.Func, "runtime.") {
+			return f, true
+		}
+	}
+	return Frame{}, false
+}
+
+// writeDump writes the goroutines of a dump, grouped by stack, followed by the other lines of the dump.
+// the stack of the goroutine that panicked is written in full, the others as their top frame
+// unless the printer expands them.
+func (p *Printer) writeDump(b *bytes.Buffer, d Dump) {
+	theme := p.theme()
+	b.WriteString(fmt.Sprintf("\t%s %s\n", theme.style("stacktrace").Sprintf("%q:", "goroutines"),
+		theme.style("muted").Sprintf("%d %s, %d %s", d.Count(), plural(d.Count(), "goroutine", "goroutines"),
+			len(d.Goroutines), plural(len(d.Goroutines), "distinct stack", "distinct stacks"))))
+
+	for i, g := range d.Goroutines {
+		head := fmt.Sprintf("goroutine %d [%s]", g.IDs[0], g.State)
+		if len(g.IDs) > 1 {
+			head = fmt.Sprintf("%d× goroutines %s [%s]", len(g.IDs), joinIDs(g.IDs, 5), g.State)
+		}
+
+		if i > 0 && !p.ExpandGoroutines {
+			top := "(no frames)"
+			if len(g.Frames) > 0 {
+				top = g.Frames[0].String()
+			}
+			b.WriteString(fmt.Sprintf("\t%s%s %s\n", treeIndent, theme.style("goroutine").Sprintf("%s:", head), theme.style("muted").Sprintf("%s", top)))
+			continue
+		}
+
+		style := theme.style("goroutine")
+		if i == 0 {
+			style = theme.style("stacktrace")
+		}
+		b.WriteString(fmt.Sprintf("\t%s%s\n", treeIndent, style.Sprintf("%s:", head)))
+		frames := g.Frames
+		if g.CreatedBy.Func != "" {
+			frames = append(frames[:len(frames):len(frames)], Frame{Func: "created by " + g.CreatedBy.Func, File: g.CreatedBy.File, Line: g.CreatedBy.Line})
+		}
+		for _, f := range frames {
+			b.WriteString(fmt.Sprintf("\t%s%s> %s", treeIndent, treeIndent, f.Func))
+			if f.File != "" {
+				b.WriteString(" " + theme.style("muted").Sprintf("%s:%d", shortCaller(f.File), f.Line))
+			}
+			b.WriteString("\n")
+		}
+	}
+	for _, l := range d.Lines {
+		b.WriteString(fmt.Sprintf("\t%s%s\n", treeIndent, theme.style("muted").Sprintf("%s", l)))
+	}
+}
+
+// joinIDs joins the goroutine ids, eliding them after max.
+func joinIDs(ids []int, max int) string {
+	parts := make([]string, 0, max+1)
+	for i, id := range ids {
+		if i == max {
+			parts = append(parts, "…")
+			break
+		}
+		parts = append(parts, strconv.Itoa(id))
+	}
+	return strings.Join(parts, ", ")
+}
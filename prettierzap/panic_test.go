@@ -0,0 +1,102 @@
+package prettierzap
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+const testDump = `panic: runtime error: index out of range [3] with length 3
+
+goroutine 1 [running]:
+main.get(...)
+	/src/app/main.go:8
+main.main()
+	/src/app/main.go:14 +0x1d
+
+goroutine 18 [chan receive]:
+main.worker(0xc000010000)
+	/src/app/worker.go:21 +0x45
+created by main.main in goroutine 1
+	/src/app/main.go:11 +0x5a
+
+goroutine 19 [chan receive, 2 minutes]:
+main.worker(0xc000010008)
+	/src/app/worker.go:21 +0x45
+created by main.main in goroutine 1
+	/src/app/main.go:11 +0x5a
+exit status 2`
+
+func TestParseDump(t *testing.T) {
+	d, ok := ParseDump(testDump)
+	if !ok {
+		t.Fatalf("ParseDump: expected the dump to be parsed")
+	}
+	if d.Reason != "panic: runtime error: index out of range [3] with length 3" {
+		t.Errorf("checkReason: expected the first line received: %v", d.Reason)
+	}
+	if d.Count() != 3 || len(d.Goroutines) != 2 {
+		t.Fatalf("checkGoroutines: expected 3 goroutines in 2 stacks received: %d in %d", d.Count(), len(d.Goroutines))
+	}
+
+	g := d.Goroutines[0]
+	if g.State != "running" || len(g.Frames) != 2 || g.Frames[0].Func != "main.get" || g.Frames[1].Line != 14 {
+		t.Errorf("checkFrames: expected the stack of the panicking goroutine received: %+v", g)
+	}
+
+	w := d.Goroutines[1]
+	if len(w.IDs) != 2 || w.IDs[1] != 19 || w.CreatedBy.Func != "main.main" || w.CreatedBy.Line != 11 {
+		t.Errorf("checkGroup: expected the workers grouped received: %+v", w)
+	}
+
+	if want := []string{"exit status 2"}; !reflect.DeepEqual(d.Lines, want) {
+		t.Errorf("checkLines: expected %v received: %v", want, d.Lines)
+	}
+
+	if _, ok := ParseDump("goroutine 1 [running]:"); ok {
+		t.Errorf("ParseDump: expected a dump to start with its reason")
+	}
+}
+
+func TestPanicRecord(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	a := NewAssembler(keyedParser(parseAuto))
+	var records []ParsedJSON
+	for _, l := range strings.Split(`{"level":"info","msg":"starting"}`+"\n"+testDump, "\n") {
+		if pj, ok := a.Add([]byte(l)); ok {
+			records = append(records, pj)
+		}
+	}
+	if pj, ok := a.Flush(); ok {
+		records = append(records, pj)
+	}
+	if len(records) != 2 {
+		t.Fatalf("checkRecords: expected the log and the panic received: %d records", len(records))
+	}
+
+	pj := records[1]
+	if levelOf(pj) != fatalLevel || callerOf(pj) != "app/main.go:8" {
+		t.Errorf("checkPanic: expected a fatal record at the panic received: %v %v", levelOf(pj), callerOf(pj))
+	}
+	if n, _ := pj.GetNumber("goroutines"); n != 3 {
+		t.Errorf("checkPanic: expected the number of goroutines received: %v", n)
+	}
+
+	s, _ := GenerateOutputString(pj, false)
+	for _, want := range []string{"3 goroutines, 2 distinct stacks", "goroutine 1 [running]:", "> main.get app/main.go:8", "2× goroutines 18, 19 [chan receive]: main.worker app/worker.go:21"} {
+		if !strings.Contains(s, want) {
+			t.Errorf("GenerateOutputString: expected %q in the output received: %v", want, s)
+		}
+	}
+	if strings.Contains(s, "+0x1d") {
+		t.Errorf("GenerateOutputString: expected the raw dump not to be written received: %v", s)
+	}
+	if i := strings.Index(s, "exit status 2"); i < strings.Index(s, "2× goroutines") || strings.Count(s, "exit status 2") != 1 {
+		t.Errorf("checkLines: expected the other lines of the dump after the goroutines received: %v", s)
+	}
+}
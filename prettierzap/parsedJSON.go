@@ -1,45 +1,146 @@
 package prettierzap
 
+import (
+	"time"
+)
+
+// parsedLog represents the fields of a log, its core fields are read with the default key mapping.
 type parsedLog map[string]string
 
-// GetLevel returns the level of log
+// GetLevel returns the level of the log
 func (pl parsedLog) GetLevel() string {
-	return pl["level"]
+	return pl.firstKey(defaultKeys.Level)
 }
 
 // GetTimestamp returns the timestamp of the log
+// the timestamp is read from the first of the time keys the log has, `ts` by default
 func (pl parsedLog) GetTimestamp() string {
-	return pl["ts"]
+	return pl.firstKey(defaultKeys.Time)
+}
+
+// GetTime returns the time of the log, read from its timestamp with the configured time encoding
+func (pl parsedLog) GetTime() (time.Time, error) {
+	return timeOf(pl.GetTimestamp())
 }
 
 // GetCaller returns the caller field of the log
 func (pl parsedLog) GetCaller() string {
-	return pl["caller"]
+	return pl.firstKey(defaultKeys.Caller)
 }
 
 // GetMsg returns the message of the log
 func (pl parsedLog) GetMsg() string {
-	keys := []string{"msg", "message"}
+	return pl.firstKey(defaultKeys.Message)
+}
 
-	for _, k := range keys {
-		if v, ok := pl[k]; ok {
-			return v
-		}
-	}
-	return ""
+// GetName returns the name of the logger that wrote the log, set by zap's logger.Named
+func (pl parsedLog) GetName() string {
+	return pl.firstKey(defaultKeys.Name)
 }
 
 // GetMeta returns meta data of the log
-// meta data is every key-value pair that its key is not in ("msg", "message", "caller", "ts", "level")
+// meta data is every key-value pair that its key is not a key of the level, time, caller, message or logger name
 func (pl parsedLog) GetMeta() map[string]string {
-	m := make(map[string]string, 0)
+	return pl.meta(defaultKeys)
+}
+
+// meta returns the fields of the log that aren't core fields of the key mapping.
+func (pl parsedLog) meta(km *KeyMapping) map[string]string {
+	m := make(map[string]string, len(pl))
 	for key := range pl {
-		switch key {
-		case "level", "ts", "caller", "msg", "message":
+		if km.isCore(key) {
 			continue
-		default:
-			m[key] = pl[key]
 		}
+		m[key] = pl[key]
 	}
 	return m
 }
+
+// GetLevel returns the level of the log
+func (lr logRecord) GetLevel() string {
+	return lr.firstKey(lr.km.Level)
+}
+
+// GetTimestamp returns the timestamp of the log
+func (lr logRecord) GetTimestamp() string {
+	return lr.firstKey(lr.km.Time)
+}
+
+// GetTime returns the time of the log, read from its timestamp with the configured time encoding
+// unless pz wrote it
+func (lr logRecord) GetTime() (time.Time, error) {
+	if !lr.at.IsZero() {
+		return lr.at, nil
+	}
+	return timeOf(lr.GetTimestamp())
+}
+
+// GetCaller returns the caller field of the log
+func (lr logRecord) GetCaller() string {
+	return lr.firstKey(lr.km.Caller)
+}
+
+// GetMsg returns the message of the log
+func (lr logRecord) GetMsg() string {
+	return lr.firstKey(lr.km.Message)
+}
+
+// GetName returns the name of the logger that wrote the log
+func (lr logRecord) GetName() string {
+	return lr.firstKey(lr.km.Name)
+}
+
+// GetMeta returns meta data of the log, the fields that aren't core fields of its key mapping
+func (lr logRecord) GetMeta() map[string]string {
+	return lr.meta(lr.km)
+}
+
+// GetValue returns the decoded value of the given key
+func (pl parsedLog) GetValue(key string) (Value, bool) {
+	raw, ok := pl[key]
+	if !ok {
+		return Value{}, false
+	}
+	return valueOf(raw), true
+}
+
+// GetString returns the decoded string of the given key, if it is a string
+func (pl parsedLog) GetString(key string) (string, bool) {
+	v, _ := pl.GetValue(key)
+	return v.AsString()
+}
+
+// GetNumber returns the number of the given key, if it is a number
+func (pl parsedLog) GetNumber(key string) (float64, bool) {
+	v, _ := pl.GetValue(key)
+	return v.AsNumber()
+}
+
+// GetBool returns the boolean of the given key, if it is a boolean
+func (pl parsedLog) GetBool(key string) (bool, bool) {
+	v, _ := pl.GetValue(key)
+	return v.AsBool()
+}
+
+// IsNull reports whether the given key exists and is null
+func (pl parsedLog) IsNull(key string) bool {
+	v, _ := pl.GetValue(key)
+	return v.IsNull()
+}
+
+// GetObject returns the members of the given key, if it is an object
+func (pl parsedLog) GetObject(key string) ([]Member, bool) {
+	v, _ := pl.GetValue(key)
+	return v.AsObject()
+}
+
+// GetArray returns the elements of the given key, if it is an array
+func (pl parsedLog) GetArray(key string) ([]Value, bool) {
+	v, _ := pl.GetValue(key)
+	return v.AsArray()
+}
+
+// Lookup returns the decoded value at the given field path like `http.status` or `tags[0]`
+func (pl parsedLog) Lookup(path string) (Value, bool) {
+	return lookupPath(pl, path)
+}
@@ -0,0 +1,322 @@
+package prettierzap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Parser represents a parser of one input format, turning a line of the input into a parsed log.
+type Parser interface {
+	// Parse parses the line, it fails if the line isn't in the format of the parser.
+	Parse(line []byte) (ParsedJSON, error)
+}
+
+// ParserFunc is an adapter to use an ordinary function as a parser.
+type ParserFunc func(line []byte) (ParsedJSON, error)
+
+// Parse calls f(line).
+func (f ParserFunc) Parse(line []byte) (ParsedJSON, error) {
+	return f(line)
+}
+
+var errPlainText = errors.New("plain text line")
+
+// keyedParser is a built-in parser, reading the core fields of logs with a key mapping.
+type keyedParser func(line []byte, km *KeyMapping) (ParsedJSON, error)
+
+// Parse parses the line, reading the core fields with the default key mapping.
+func (f keyedParser) Parse(line []byte) (ParsedJSON, error) {
+	return f(line, defaultKeys)
+}
+
+// parseKeys parses the line with the parser, reading the core fields with the key mapping if it is a built-in one.
+func parseKeys(p Parser, line []byte, km *KeyMapping) (ParsedJSON, error) {
+	if kp, ok := p.(keyedParser); ok {
+		return kp(line, km.orDefault())
+	}
+	return p.Parse(line)
+}
+
+// names of the built-in input formats
+const (
+	FormatAuto    = "auto"    // detect the format of each line
+	FormatJSON    = "json"    // zap JSON encoder, or any JSON object read with the key mapping
+	FormatSlog    = "slog"    // log/slog JSON handler
+	FormatLogrus  = "logrus"  // logrus JSON formatter
+	FormatConsole = "console" // zap console encoder, the default of zap's development config
+	FormatLogfmt  = "logfmt"  // key=value pairs, like go-kit and logrus text formatters
+	FormatText    = "text"    // any line, taken as a debug message
+)
+
+var (
+	parsersMu sync.RWMutex
+	parsers   = map[string]Parser{
+		FormatJSON:    keyedParser(parseJSON),
+		FormatSlog:    keyedParser(parseSlog),
+		FormatLogrus:  keyedParser(parseLogrus),
+		FormatConsole: keyedParser(parseConsole),
+		FormatLogfmt:  keyedParser(parseLogfmt),
+		FormatText:    keyedParser(parseText),
+	}
+)
+
+func init() {
+	// the auto parser looks up the other parsers, so it can't be in the initializer of the registry
+	parsers[FormatAuto] = keyedParser(parseAuto)
+}
+
+// RegisterParser adds a parser for the input format with the given name, replacing any parser of that name.
+// format names are case-insensitive.
+func RegisterParser(name string, p Parser) {
+	parsersMu.Lock()
+	defer parsersMu.Unlock()
+	parsers[strings.ToLower(name)] = p
+}
+
+// LookupParser returns the parser of the input format with the given name.
+func LookupParser(name string) (Parser, error) {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	p, ok := parsers[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q", name)
+	}
+	return p, nil
+}
+
+// ParserNames returns the names of the registered input formats, sorted.
+func ParserNames() []string {
+	parsersMu.RLock()
+	defer parsersMu.RUnlock()
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseLine parses the line with the given parser, reading the core fields with the default key mapping.
+// lines the parser fails on are kept as debug messages, like lines of plain text.
+func ParseLine(p Parser, line []byte) (ParsedJSON, bool) {
+	return parseLine(p, line, defaultKeys)
+}
+
+// parseLine parses the line like ParseLine, reading the core fields with the key mapping.
+func parseLine(p Parser, line []byte, km *KeyMapping) (ParsedJSON, bool) {
+	if len(line) == 0 {
+		return nil, false
+	}
+	pj, err := parseKeys(p, line, km)
+	if err != nil {
+		pj, _ = parseText(line, km)
+	}
+	return pj, true
+}
+
+// DetectFormat returns the name of the built-in format of the line.
+func DetectFormat(line []byte) string {
+	format, _ := detectFormat(line, defaultKeys)
+	return format
+}
+
+// detectFormat returns the name of the built-in format of the line,
+// and the record of the line if it is a JSON object, so it isn't scanned twice.
+func detectFormat(line []byte, km *KeyMapping) (string, logRecord) {
+	if i := firstNonSpace(line); i < len(line) && line[i] == '{' {
+		if lr, err := scanLog(line, km); err == nil {
+			return detectJSONFormat(lr.parsedLog, km), lr
+		}
+	}
+	if _, err := parseConsole(line, km); err == nil {
+		return FormatConsole, logRecord{}
+	}
+	if pl, err := scanLogfmt(line); err == nil && hasCandidateKey(pl) {
+		return FormatLogfmt, logRecord{}
+	}
+	return FormatText, logRecord{}
+}
+
+// firstNonSpace returns the index of the first byte of the line that isn't a space.
+func firstNonSpace(line []byte) int {
+	i := 0
+	for i < len(line) && (line[i] == ' ' || line[i] == '\t' || line[i] == '\r' || line[i] == '\n') {
+		i++
+	}
+	return i
+}
+
+// detectJSONFormat tells the JSON encoders apart by the shape of their logs first, and by the keys they
+// write otherwise: logs with the time key of the key mapping are read as they are.
+func detectJSONFormat(pl parsedLog, km *KeyMapping) string {
+	shape := jsonShape{level: pl["level"], source: valueOf(pl["source"]).Kind()}
+	_, shape.time = pl["time"]
+	_, shape.file = pl["file"]
+	_, shape.function = pl["func"]
+	if format := shape.format(); format != "" {
+		return format
+	}
+
+	if pl.firstKey(km.Time) != "" || !shape.time || !strings.HasPrefix(shape.level, `"`) {
+		return FormatJSON
+	}
+	return FormatLogrus
+}
+
+// jsonShape represents the members of a JSON log telling the encoder that wrote it apart.
+type jsonShape struct {
+	level    string // raw value of the level
+	time     bool   // the log has a time
+	source   Kind   // kind of the source
+	file     bool   // the log has the file of the caller
+	function bool   // the log has the function of the caller
+}
+
+// format returns the format of the encoder the shape is unique to: slog writes its source as an object
+// and its levels in upper case, logrus writes the file and the function of the caller. both write a time
+// and a string level. it returns nothing for the other shapes.
+func (s jsonShape) format() string {
+	if !s.time || len(s.level) < 2 || s.level[0] != '"' {
+		return ""
+	}
+	level := s.level[1 : len(s.level)-1]
+	switch {
+	case s.source == ObjectKind:
+		return FormatSlog
+	case s.file && s.function:
+		return FormatLogrus
+	case level != "" && level == strings.ToUpper(level):
+		return FormatSlog
+	}
+	return ""
+}
+
+// hasCandidateKey reports whether the log has one of the keys used for the level or the message.
+func hasCandidateKey(pl parsedLog) bool {
+	for _, ks := range [][]string{keyCandidates.Level, keyCandidates.Message} {
+		if pl.firstKey(ks) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAuto parses the line with the parser of its detected format.
+// it fails on lines of plain text, so they can be told apart from the lines of loggers.
+func parseAuto(line []byte, km *KeyMapping) (ParsedJSON, error) {
+	format, lr := detectFormat(line, km)
+	switch format {
+	case FormatText:
+		return nil, errPlainText
+	case FormatJSON:
+		return lr, nil
+	case FormatSlog:
+		return lr.withFields(fromSlog(lr.parsedLog, km)), nil
+	case FormatLogrus:
+		return lr.withFields(fromLogrus(lr.parsedLog, km)), nil
+	}
+
+	p, err := LookupParser(format)
+	if err != nil {
+		return nil, err
+	}
+	return parseKeys(p, line, km)
+}
+
+// parseJSON parses a JSON object, keeping the raw text of every top-level value.
+// nested objects, arrays and escaped strings are kept intact.
+func parseJSON(line []byte, km *KeyMapping) (ParsedJSON, error) {
+	lr, err := scanLog(line, km)
+	if err != nil {
+		return nil, err
+	}
+	return lr, nil
+}
+
+// textCaller is the caller of lines of plain text.
+const textCaller = "user-code"
+
+// parseText takes the line as a debug message, written now by the user code.
+// its timestamp is the epoch time of now, and its time is now whatever the time encoding is.
+func parseText(line []byte, km *KeyMapping) (ParsedJSON, error) {
+	now := time.Now()
+	pl := parsedLog{}
+	pl[first(km.Level, "level")] = fmt.Sprintf("%q", debugLevel)
+	pl[first(km.Time, "ts")] = fmt.Sprintf("%v", now.Unix())
+	pl[first(km.Caller, "caller")] = jsonQuote(textCaller)
+	pl[first(km.Message, "msg")] = strings.TrimSpace(string(line))
+	lr := newRecord(pl, nil, km)
+	lr.at = now
+	return lr, nil
+}
+
+// normalizeKeys moves the core fields of a log of another encoder to the keys of the key mapping,
+// so logs of every format are read the same way.
+// a field is moved from the first candidate key of its kind the log has, if the log has none of the mapped keys.
+func normalizeKeys(pl parsedLog, km *KeyMapping) parsedLog {
+	for _, f := range []struct{ mapped, candidates []string }{
+		{km.Level, keyCandidates.Level},
+		{km.Time, keyCandidates.Time},
+		{km.Caller, keyCandidates.Caller},
+		{km.Message, keyCandidates.Message},
+		{km.Name, keyCandidates.Name},
+		{km.Stacktrace, keyCandidates.Stacktrace},
+	} {
+		if len(f.mapped) == 0 || pl.firstKey(f.mapped) != "" {
+			continue
+		}
+		for _, k := range f.candidates {
+			if v, ok := pl[k]; ok {
+				delete(pl, k)
+				pl[f.mapped[0]] = v
+				break
+			}
+		}
+	}
+	return pl
+}
+
+// shortCaller trims the path of a source file to its last directory, like zapcore.ShortCallerEncoder.
+func shortCaller(file string) string {
+	i := strings.LastIndexByte(file, '/')
+	if i < 0 {
+		return file
+	}
+	if j := strings.LastIndexByte(file[:i], '/'); j >= 0 {
+		return file[j+1:]
+	}
+	return file
+}
+
+// jsonQuote returns the JSON string literal of s.
+func jsonQuote(s string) string {
+	var b strings.Builder
+	b.Grow(len(s) + 2)
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		default:
+			if r < 0x20 {
+				fmt.Fprintf(&b, `\u%04x`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
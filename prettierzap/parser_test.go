@@ -0,0 +1,173 @@
+package prettierzap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		Line   string
+		Wanted string
+	}{
+		{"zap json", `{"level":"info","ts":1522426145.187,"caller":"main.go:12","msg":"hi"}`, FormatJSON},
+		{"slog json", `{"time":"2018-03-30T16:09:05.187+04:30","level":"INFO","msg":"hi","user":"test"}`, FormatSlog},
+		{"slog json with source", `{"time":"2018-03-30T16:09:05Z","level":"info","source":{"function":"main.main","file":"/src/app/main.go","line":12},"msg":"hi"}`, FormatSlog},
+		{"logrus json", `{"level":"warning","msg":"hi","time":"2018-03-30T16:09:05+04:30"}`, FormatLogrus},
+		{"logrus json with caller", `{"file":"/src/app/main.go:12","func":"main.main","level":"info","msg":"hi","time":"2018-03-30T16:09:05Z","ts":1}`, FormatLogrus},
+		{"zap json with time", `{"level":"info","ts":1522426145.187,"msg":"hi","time":"2018-03-30T16:09:05Z"}`, FormatJSON},
+		{"zap console", "2018-03-30T16:09:05.187+0430\tINFO\tmain.go:12\thi", FormatConsole},
+		{"zap console colored", "2018-03-30T16:09:05.187+0430\t\x1b[34mINFO\x1b[0m\thi", FormatConsole},
+		{"logfmt", `ts=2018-03-30T16:09:05Z level=info msg="hello world" status=200`, FormatLogfmt},
+		{"text", `panic: runtime error: index out of range`, FormatText},
+		{"text with equals", `a=b but no known key`, FormatText},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			if f := DetectFormat([]byte(tc.Line)); f != tc.Wanted {
+				t.Errorf("DetectFormat: expected %v received: %v", tc.Wanted, f)
+			}
+		})
+	}
+}
+
+func TestParsers(t *testing.T) {
+	type checkFunc func(ParsedJSON) error
+	check := func(fns ...checkFunc) []checkFunc { return fns }
+
+	hasCore := func(level, msg, caller string) checkFunc {
+		return func(pj ParsedJSON) error {
+			if l := levelOf(pj); l != level {
+				return fmt.Errorf("checkLevel: expected level: %v received: %v", level, l)
+			}
+			if m := msgOf(pj); m != msg {
+				return fmt.Errorf("checkMsg: expected msg: %v received: %v", msg, m)
+			}
+			if c := callerOf(pj); c != caller {
+				return fmt.Errorf("checkCaller: expected caller: %v received: %v", caller, c)
+			}
+			return nil
+		}
+	}
+	hasTime := func(unix int64) checkFunc {
+		return func(pj ParsedJSON) error {
+			tm, err := pj.GetTime()
+			if err != nil || tm.Unix() != unix {
+				return fmt.Errorf("checkTime: expected time: %v received: %v (%v)", unix, tm.Unix(), err)
+			}
+			return nil
+		}
+	}
+	hasName := func(name string) checkFunc {
+		return func(pj ParsedJSON) error {
+			if n := nameOf(pj); n != name {
+				return fmt.Errorf("checkName: expected logger: %v received: %v", name, n)
+			}
+			return nil
+		}
+	}
+	hasMeta := func(key, raw string) checkFunc {
+		return func(pj ParsedJSON) error {
+			if v, ok := pj.GetMeta()[key]; !ok || v != raw {
+				return fmt.Errorf("checkMeta: expected %v: %v received: %v", key, raw, pj.GetMeta())
+			}
+			return nil
+		}
+	}
+
+	testScenarios := map[string]struct {
+		format      string
+		line        string
+		checkResult []checkFunc
+	}{
+		"slog": {
+			FormatAuto,
+			`{"time":"2018-03-30T16:09:05Z","level":"WARN","source":{"function":"main.main","file":"/src/app/main.go","line":12},"msg":"hi","user":"test"}`,
+			check(hasCore("WARN", "hi", "app/main.go:12"), hasTime(1522426145), hasMeta("user", `"test"`)),
+		},
+		"logrus": {
+			FormatAuto,
+			`{"file":"/src/app/main.go:12","func":"main.main","level":"warning","msg":"hi","time":"2018-03-30T16:09:05Z"}`,
+			check(hasCore("warning", "hi", "app/main.go:12"), hasTime(1522426145), hasMeta("func", `"main.main"`)),
+		},
+		"slog without source": {
+			FormatAuto,
+			`{"time":"2018-03-30T16:09:05Z","level":"INFO","msg":"hi","user":"test"}`,
+			check(hasCore("INFO", "hi", ""), hasTime(1522426145), hasMeta("user", `"test"`)),
+		},
+		"logrus without caller": {
+			FormatAuto,
+			`{"level":"info","msg":"hi","time":"2018-03-30T16:09:05Z","user":"test"}`,
+			check(hasCore("info", "hi", ""), hasTime(1522426145), hasMeta("user", `"test"`)),
+		},
+		"zap with a time field": {
+			FormatAuto,
+			`{"level":"info","ts":1522426145,"caller":"main.go:12","msg":"hi","time":"yesterday"}`,
+			check(hasCore("info", "hi", "main.go:12"), hasTime(1522426145)),
+		},
+		"console": {
+			FormatAuto,
+			"2018-03-30T16:09:05.187Z\tERROR\tapi.http\tserver/http.go:42\trequest failed\t{\"status\": 500, \"path\": \"/\"}",
+			check(hasCore("ERROR", "request failed", "server/http.go:42"), hasTime(1522426145), hasName("api.http"), hasMeta("status", "500")),
+		},
+		"console without caller": {
+			FormatConsole,
+			"1522426145.187\tinfo\tdb\tconnected",
+			check(hasCore("info", "connected", ""), hasTime(1522426145), hasName("db")),
+		},
+		"logfmt": {
+			FormatAuto,
+			`time=2018-03-30T16:09:05Z level=info caller=main.go:12 msg="hello \"world\"" status=200 cached`,
+			check(hasCore("info", `hello "world"`, "main.go:12"), hasTime(1522426145), hasMeta("status", "200"), hasMeta("cached", "true")),
+		},
+		"wrong format": {
+			FormatLogfmt,
+			`not logfmt at all`,
+			check(hasCore("debug", "not logfmt at all", "user-code")),
+		},
+	}
+
+	for name, ts := range testScenarios {
+		t.Run(name, func(t *testing.T) {
+			p, err := LookupParser(ts.format)
+			if err != nil {
+				t.Fatalf("LookupParser: expected no error received: %v", err)
+			}
+
+			// the keys detected from the line don't change how it is read
+			d := KeyDetector{Base: DefaultKeyMapping(), Lines: DefaultDetectLines}
+			for _, km := range []*KeyMapping{defaultKeys, d.Observe([]byte(ts.line))} {
+				pj, ok := parseLine(p, []byte(ts.line), km)
+				if !ok {
+					t.Fatalf("ParseLine: expected the line to be parsed")
+				}
+				for _, c := range ts.checkResult {
+					if e := c(pj); e != nil {
+						t.Errorf("keys %+v: %v", *km, e)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRegisterParser(t *testing.T) {
+	RegisterParser("Upper", ParserFunc(func(line []byte) (ParsedJSON, error) {
+		return parsedLog{"level": `"info"`, "msg": jsonQuote(string(line))}, nil
+	}))
+
+	p, err := LookupParser("upper")
+	if err != nil {
+		t.Fatalf("LookupParser: expected the registered parser received: %v", err)
+	}
+	pj, _ := ParseLine(p, []byte("hi"))
+	if msgOf(pj) != "hi" {
+		t.Errorf("ParseLine: expected the registered parser to be used received: %v", msgOf(pj))
+	}
+
+	if _, err := LookupParser("xml"); err == nil {
+		t.Errorf("LookupParser: expected an error for an unknown format")
+	}
+}
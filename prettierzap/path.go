@@ -0,0 +1,122 @@
+package prettierzap
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var errInvalidPath = errors.New("invalid field path")
+
+// pathSegment represents one step of a field path, either an object key or an array index.
+type pathSegment struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parsePath splits a field path like `http.request.id`, `tags[0]` or `headers["x.forwarded"]`
+// into its segments.
+func parsePath(path string) ([]pathSegment, error) {
+	segments := make([]pathSegment, 0, strings.Count(path, ".")+1)
+
+	i := 0
+	expectKey := true
+	for i < len(path) {
+		switch c := path[i]; {
+		case c == '[':
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				return nil, errInvalidPath
+			}
+			inner := path[i+1 : i+end]
+			if strings.HasPrefix(inner, `"`) {
+				key, ok := unquote([]byte(inner))
+				if !ok {
+					return nil, errInvalidPath
+				}
+				segments = append(segments, pathSegment{key: key})
+			} else {
+				n, err := strconv.Atoi(inner)
+				if err != nil || n < 0 {
+					return nil, errInvalidPath
+				}
+				segments = append(segments, pathSegment{index: n, isIndex: true})
+			}
+			i += end + 1
+			expectKey = false
+		case c == '.':
+			if expectKey {
+				return nil, errInvalidPath
+			}
+			i++
+			expectKey = true
+		default:
+			if !expectKey {
+				return nil, errInvalidPath
+			}
+			end := strings.IndexAny(path[i:], ".[")
+			if end < 0 {
+				end = len(path) - i
+			}
+			segments = append(segments, pathSegment{key: path[i : i+end]})
+			i += end
+			expectKey = false
+		}
+	}
+
+	if expectKey {
+		return nil, errInvalidPath
+	}
+	return segments, nil
+}
+
+// walk follows the given segments starting from the value.
+func (v Value) walk(segments []pathSegment) (Value, bool) {
+	for _, s := range segments {
+		if s.isIndex {
+			elems, ok := v.AsArray()
+			if !ok || s.index >= len(elems) {
+				return Value{}, false
+			}
+			v = elems[s.index]
+			continue
+		}
+
+		m, ok := v.Get(s.key)
+		if !ok {
+			return Value{}, false
+		}
+		v = m
+	}
+	return v, true
+}
+
+// Lookup returns the value at the given field path inside the value.
+func (v Value) Lookup(path string) (Value, bool) {
+	segments, err := parsePath(path)
+	if err != nil {
+		return Value{}, false
+	}
+	return v.walk(segments)
+}
+
+// lookupPath resolves a field path against a log.
+// a top-level key that literally matches the whole path, like zap's flattened `http.status`,
+// wins over walking into nested objects.
+func lookupPath(pj ParsedJSON, path string) (Value, bool) {
+	if v, ok := pj.GetValue(path); ok {
+		return v, true
+	}
+
+	segments, err := parsePath(path)
+	if err != nil || len(segments) < 2 || segments[0].isIndex {
+		return Value{}, false
+	}
+
+	root, ok := pj.GetValue(segments[0].key)
+	if !ok {
+		return Value{}, false
+	}
+	return root.walk(segments[1:])
+}
@@ -0,0 +1,38 @@
+package prettierzap
+
+import (
+	"testing"
+)
+
+func TestLookupPath(t *testing.T) {
+	pj, _ := ParseJSONByteArray([]byte(`{"level":"error","msg":"failed","http":{"status":500,"request":{"id":"abc","headers":{"x.forwarded":"1.2.3.4"}}},"tags":["x","y"],"items":[{"id":1},{"id":2}],"db.name":"users"}`))
+
+	testScenarios := []struct {
+		Name   string
+		Path   string
+		Wanted string
+		Found  bool
+	}{
+		{"pass - top-level key", "msg", `"failed"`, true},
+		{"pass - nested key", "http.status", "500", true},
+		{"pass - deeply nested key", "http.request.id", `"abc"`, true},
+		{"pass - array index", "tags[0]", `"x"`, true},
+		{"pass - key inside array element", "items[1].id", "2", true},
+		{"pass - quoted key with a dot", `http.request.headers["x.forwarded"]`, `"1.2.3.4"`, true},
+		{"pass - flattened key with a dot", "db.name", `"users"`, true},
+		{"fails - missing key", "http.method", "", false},
+		{"fails - index out of range", "tags[2]", "", false},
+		{"fails - index into an object", "http[0]", "", false},
+		{"fails - malformed path", "http..status", "", false},
+		{"fails - trailing dot", "http.", "", false},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			v, ok := pj.Lookup(tc.Path)
+			if ok != tc.Found || v.Raw() != tc.Wanted {
+				t.Errorf("Lookup(%q): expected (%v, %v) received: (%v, %v)", tc.Path, tc.Wanted, tc.Found, v.Raw(), ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package prettierzap
+
+import (
+	"bufio"
+	"io"
+	"sync"
+)
+
+// DefaultBatchSize is the number of lines or records handed to a worker of a pipeline at once.
+const DefaultBatchSize = 512
+
+// lineBatch represents a batch of lines of a reader parsed by a worker.
+type lineBatch struct {
+	lines   [][]byte
+	dropped []int
+	keys    []*KeyMapping // key mappings to read the lines with
+	heads   []ParsedJSON  // records of the lines, or the records they start when assembled
+	starts  []bool        // whether the lines start records, or could be parsed at all
+	skips   []bool        // whether the lines start records rejected by the prefilter
+	idle    bool          // the source waits for new lines after the batch
+	err     error         // error ending the source after the batch
+	done    chan struct{}
+}
+
+// startWorkers starts the goroutines reading the lines of the reader and parsing them on its workers.
+// batches are queued in the order of the input, each one ready once its done channel is closed.
+func (r *LogReader) startWorkers() {
+	jobs := make(chan *lineBatch, r.Workers)
+	r.batches = make(chan *lineBatch, 2*r.Workers)
+
+	for i := 0; i < r.Workers; i++ {
+		go func() {
+			for b := range jobs {
+				r.parseBatch(b)
+				close(b.done)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		defer close(r.batches)
+		for {
+			b := &lineBatch{done: make(chan struct{})}
+			for len(b.lines) < DefaultBatchSize {
+				l, dropped, err := r.lines.ReadLine()
+				if isTemporary(err) {
+					b.idle = true
+					break
+				}
+				if err != nil {
+					b.err = err
+					break
+				}
+				b.keys = append(b.keys, r.keysOf(l))
+				b.lines = append(b.lines, append([]byte(nil), l...))
+				b.dropped = append(b.dropped, dropped)
+			}
+
+			jobs <- b
+			r.batches <- b
+			if b.err != nil {
+				return
+			}
+		}
+	}()
+}
+
+// parseBatch parses the lines of the batch.
+func (r *LogReader) parseBatch(b *lineBatch) {
+	b.heads = make([]ParsedJSON, len(b.lines))
+	b.starts = make([]bool, len(b.lines))
+	b.skips = make([]bool, len(b.lines))
+	for i, l := range b.lines {
+		switch {
+		case r.rejects(l, b.dropped[i], b.keys[i]):
+			b.skips[i] = true
+		case r.Assembler != nil:
+			b.heads[i], b.starts[i] = r.Assembler.parseStart(l, b.dropped[i], b.keys[i])
+		default:
+			b.heads[i], b.starts[i] = r.parseLine(l, b.dropped[i], b.keys[i])
+		}
+	}
+}
+
+// nextParallel returns the next record of the lines parsed by the workers.
+func (r *LogReader) nextParallel() (ParsedJSON, error) {
+	if r.batches == nil {
+		r.startWorkers()
+	}
+
+	for {
+		for r.batch != nil && r.index < len(r.batch.lines) {
+			i := r.index
+			r.index++
+
+			var (
+				pj ParsedJSON
+				ok bool
+			)
+			switch {
+			case r.batch.skips[i]:
+				if r.Assembler != nil {
+					pj, ok = r.Assembler.addRejected()
+				}
+			case r.Assembler != nil:
+				pj, ok = r.Assembler.addParsed(r.batch.lines[i], r.batch.dropped[i], r.batch.heads[i], r.batch.starts[i], r.batch.keys[i])
+			default:
+				pj, ok = r.batch.heads[i], r.batch.starts[i]
+			}
+			if ok {
+				return r.withSource(pj), nil
+			}
+		}
+
+		if b := r.batch; b != nil {
+			r.batch = nil
+			err := b.err
+			if b.idle {
+				err = errWaiting
+			} else if err != nil {
+				r.done = true
+			}
+
+			// the record being assembled is complete when the source ends or waits
+			if err != nil {
+				if r.Assembler != nil {
+					if pj, ok := r.Assembler.Flush(); ok {
+						r.pendingErr = err
+						return r.withSource(pj), nil
+					}
+				}
+				return nil, err
+			}
+		}
+
+		if err := r.pendingErr; err != nil {
+			r.pendingErr = nil
+			return nil, err
+		}
+		if r.done {
+			return nil, io.EOF
+		}
+
+		b, ok := <-r.batches
+		if !ok {
+			return nil, io.EOF
+		}
+		<-b.done
+		r.batch, r.index = b, 0
+	}
+}
+
+// errWaiting is returned by parallel readers whose source waits for new lines.
+var errWaiting error = waitingError{}
+
+type waitingError struct{}
+
+func (waitingError) Error() string   { return "waiting for new lines" }
+func (waitingError) Temporary() bool { return true }
+
+// recordBatch represents a batch of records filtered and rendered by a worker.
+type recordBatch struct {
+	records []ParsedJSON
+	out     []byte
+	err     error
+	flush   bool // the source waits for new records after the batch
+	done    chan struct{}
+}
+
+// PrintRecords filters and renders the records returned by next on the given number of workers,
+// and writes them to w in their order, buffered.
+// next returns io.EOF at the end, the writes are flushed when it returns a temporary error
+// as it waits for new records.
+// delta timestamps depend on the previous record, so they are always rendered on one worker.
+func (p *Printer) PrintRecords(w io.Writer, next func() (ParsedJSON, error), f LogFilter, workers int) error {
+	if workers < 1 || p.TimeFormat == TimeFormatDelta {
+		workers = 1
+	}
+
+	jobs := make(chan *recordBatch, workers)
+	batches := make(chan *recordBatch, 2*workers)
+	var readErr error
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for b := range jobs {
+				for _, pj := range b.records {
+					if !filterJSON(pj, f) {
+						continue
+					}
+					s, err := p.render(pj)
+					if err != nil {
+						b.err = err
+						break
+					}
+					b.out = append(b.out, s...)
+				}
+				close(b.done)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(jobs)
+		defer close(batches)
+		for {
+			b := &recordBatch{done: make(chan struct{})}
+			var err error
+			for len(b.records) < DefaultBatchSize {
+				var pj ParsedJSON
+				if pj, err = next(); err != nil {
+					break
+				}
+				b.records = append(b.records, pj)
+			}
+			b.flush = isTemporary(err)
+
+			jobs <- b
+			batches <- b
+			if err != nil && !b.flush {
+				if err != io.EOF {
+					readErr = err
+				}
+				return
+			}
+		}
+	}()
+
+	bw := bufio.NewWriterSize(w, 64*1024)
+	h, errWrite := p.header()
+	if errWrite == nil {
+		_, errWrite = bw.WriteString(h)
+	}
+	for b := range batches {
+		<-b.done
+		if errWrite != nil {
+			continue
+		}
+		if b.err != nil {
+			errWrite = b.err
+			continue
+		}
+		if _, errWrite = bw.Write(b.out); errWrite == nil && (b.flush || len(batches) == 0) {
+			errWrite = bw.Flush()
+		}
+	}
+	wg.Wait()
+
+	if errFlush := bw.Flush(); errWrite == nil {
+		errWrite = errFlush
+	}
+	if errWrite != nil {
+		return errWrite
+	}
+	return readErr
+}
@@ -0,0 +1,222 @@
+package prettierzap
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+// testCorpus returns n lines of zap logs, with a stacktrace every 100 lines and a plain text line every 250.
+func testCorpus(n int) []string {
+	levels := []string{"debug", "info", "warn", "error"}
+	lines := make([]string, 0, n)
+	for i := 0; len(lines) < n; i++ {
+		lines = append(lines, fmt.Sprintf(`{"level":%q,"ts":%d.%03d,"logger":"api.http","caller":"http/server.go:%d","msg":"request %d served","method":"GET","path":"/v1/users/%d","status":%d,"latency":0.%03d,"user":{"id":%d,"roles":["admin","dev"]}}`,
+			levels[i%len(levels)], 1522426145+i/1000, i%1000, 40+i%7, i, i%97, 200+i%3*100, i%1000, i))
+		if i%100 == 99 {
+			lines = append(lines, "main.handler", "\t/src/app/main.go:42")
+		}
+		if i%250 == 249 {
+			lines = append(lines, fmt.Sprintf("plain line %d", i))
+		}
+	}
+	return lines[:n]
+}
+
+// readAll returns the messages of all records of the reader.
+func readAll(t testing.TB, r *LogReader) []string {
+	var msgs []string
+	for {
+		pj, err := r.Next()
+		if err == io.EOF {
+			return msgs
+		}
+		if err != nil {
+			t.Fatalf("Next: expected no error received: %v", err)
+		}
+		st, _ := pj.GetValue("stacktrace")
+		msgs = append(msgs, msgOf(pj)+"|"+st.String())
+	}
+}
+
+func TestParallelReader(t *testing.T) {
+	corpus := testCorpus(5000)
+
+	for _, multiline := range []bool{false, true} {
+		t.Run(fmt.Sprintf("multiline %v", multiline), func(t *testing.T) {
+			newReader := func(workers int) *LogReader {
+				lines := linesOf(append([]string(nil), corpus...))
+				r := NewLogReader(&lines, keyedParser(parseAuto))
+				if multiline {
+					r.Assembler = NewAssembler(r.Parser)
+				}
+				r.Workers = workers
+				return r
+			}
+
+			want := readAll(t, newReader(1))
+			got := readAll(t, newReader(4))
+			if strings.Join(got, "\n") != strings.Join(want, "\n") {
+				t.Errorf("checkRecords: expected the records of the sequential reader, received %d records instead of %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+// idleLines is a source of lines waiting for new lines after each of the given lines.
+type idleLines struct {
+	lines []string
+	idle  bool
+}
+
+func (l *idleLines) ReadLine() ([]byte, int, error) {
+	if l.idle = !l.idle; l.idle && len(l.lines) > 0 {
+		return nil, 0, errWaiting
+	}
+	if len(l.lines) == 0 {
+		return nil, 0, io.EOF
+	}
+	line := l.lines[0]
+	l.lines = l.lines[1:]
+	return []byte(line), 0, nil
+}
+
+func TestParallelReaderIdle(t *testing.T) {
+	r := NewLogReader(&idleLines{lines: []string{`{"level":"info","msg":"a"}`, "  stack of a", `{"level":"info","msg":"b"}`}}, keyedParser(parseAuto))
+	r.Assembler = NewAssembler(r.Parser)
+	r.Workers = 2
+
+	var got []string
+	for {
+		pj, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if isTemporary(err) {
+			got = append(got, "idle")
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Next: expected no error received: %v", err)
+		}
+		got = append(got, msgOf(pj))
+	}
+
+	// a record is complete once the source waits, lines coming later don't continue it
+	want := "idle a idle stack of a idle b"
+	if strings.Join(got, " ") != want {
+		t.Errorf("checkRecords: expected %v received: %v", want, strings.Join(got, " "))
+	}
+}
+
+func TestPrintRecords(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	corpus := testCorpus(3000)
+	f := LogFilter{MinLevel: "warn"}
+
+	var want bytes.Buffer
+	p := NewPrinter()
+	for _, l := range corpus {
+		pj, _ := ParseJSONByteArray([]byte(l))
+		p.PrettyPrint(&want, pj, f)
+	}
+
+	for _, workers := range []int{1, 4} {
+		lines := linesOf(append([]string(nil), corpus...))
+		r := NewLogReader(&lines, keyedParser(parseJSON))
+
+		var got bytes.Buffer
+		if err := NewPrinter().PrintRecords(&got, r.Next, f, workers); err != nil {
+			t.Fatalf("PrintRecords: expected no error received: %v", err)
+		}
+		if logLines(got.String()) != logLines(want.String()) || got.Len() != want.Len() {
+			t.Errorf("PrintRecords(%d workers): expected the output of PrettyPrint in order, received %d bytes instead of %d", workers, got.Len(), want.Len())
+		}
+	}
+}
+
+// logLines returns the first lines of the records of an output, without the meta fields whose order varies.
+func logLines(out string) string {
+	var lines []string
+	for _, l := range strings.Split(out, "\n") {
+		if l != "" && l[0] != ' ' && l[0] != '\t' {
+			lines = append(lines, l)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// corpusSource is a source of the lines of a corpus, read again and again b.N times.
+type corpusSource struct {
+	lines [][]byte
+	n     int
+}
+
+func (c *corpusSource) ReadLine() ([]byte, int, error) {
+	if c.n >= len(c.lines) {
+		return nil, 0, io.EOF
+	}
+	c.n++
+	return c.lines[c.n-1], 0, nil
+}
+
+func benchmarkPipeline(b *testing.B, workers int, f LogFilter, prefilter bool) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	corpus := testCorpus(20000)
+	lines := make([][]byte, len(corpus))
+	size := 0
+	for i, l := range corpus {
+		lines[i] = []byte(l)
+		size += len(l) + 1
+	}
+
+	b.SetBytes(int64(size))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := NewLogReader(&corpusSource{lines: lines}, keyedParser(parseAuto))
+		r.Assembler = NewAssembler(r.Parser)
+		r.Workers = workers
+		if prefilter {
+			r.Prefilter = NewPrefilter(f, nil)
+		}
+		NewPrinter().PrintRecords(ioutil.Discard, r.Next, f, workers)
+	}
+}
+
+func BenchmarkPipelineSequential(b *testing.B) {
+	benchmarkPipeline(b, 1, LogFilter{}, false)
+}
+
+func BenchmarkPipelineParallel(b *testing.B) {
+	benchmarkPipeline(b, runtime.NumCPU(), LogFilter{}, false)
+}
+
+func BenchmarkPipelineFilteredSequential(b *testing.B) {
+	benchmarkPipeline(b, 1, LogFilter{Level: "error"}, false)
+}
+
+func BenchmarkPipelineFilteredParallel(b *testing.B) {
+	benchmarkPipeline(b, runtime.NumCPU(), LogFilter{Level: "error"}, false)
+}
+
+func BenchmarkParseJSONByteArray(b *testing.B) {
+	line := []byte(testCorpus(1)[0])
+	b.SetBytes(int64(len(line)))
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		ParseJSONByteArray(line)
+	}
+}
@@ -0,0 +1,309 @@
+package prettierzap
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+)
+
+// Prefilter rejects the lines whose records can't pass a log filter by looking at their raw bytes
+// before they're parsed, so filtering a few records out of a huge input doesn't pay for parsing the others.
+// only the fields the filter refers to are read from a line, and a line missing a piece of text that
+// every passing line has isn't read at all.
+// it only rejects what it is sure about: lines that aren't JSON objects, logs of other encoders and
+// the parts of the filter it can't evaluate, like queries, are left to the filter of the parsed records.
+// the lines are expected to be parsed as JSON logs read with the key mapping, like the auto and json
+// parsers do.
+type Prefilter struct {
+	f       LogFilter
+	caller  []byte
+	meta    []metaCheck
+	needles [][]byte // pieces of text every line passing the filter contains
+}
+
+// metaCheck represents a key-value pair of a filter, checked against the raw bytes of lines.
+type metaCheck struct {
+	key  string // path of the field, or a top-level key the line has literally
+	root string // first key of the path if it goes into nested fields, empty otherwise
+	want Value
+}
+
+// NewPrefilter returns a prefilter of the lines for the given filter, read with the given key mapping,
+// the default one if nil, or the one detections start from. it returns nil if no part of the filter can be
+// evaluated on raw lines.
+func NewPrefilter(f LogFilter, km *KeyMapping) *Prefilter {
+	if f.Level == "" && f.MinLevel == "" && f.MaxLevel == "" && f.Caller == "" && len(f.Logger) == 0 &&
+		f.Since.IsZero() && f.Until.IsZero() && len(f.Meta) == 0 {
+		return nil
+	}
+
+	p := &Prefilter{
+		f:       f,
+		caller:  []byte(f.Caller),
+		needles: textPieces(f.Caller, ""),
+	}
+	if len(f.Logger) == 1 && !strings.ContainsAny(f.Logger[0], `[\`) {
+		p.needles = append(p.needles, textPieces(f.Logger[0], "*?")...)
+	}
+
+	km = km.orDefault()
+	for k, v := range f.Meta {
+		c := metaCheck{key: k, want: valueOf(*v)}
+		if segments, err := parsePath(k); err == nil && len(segments) > 1 && !segments[0].isIndex {
+			c.root = segments[0].key
+		}
+		p.meta = append(p.meta, c)
+
+		// the line has the key, or the first key of the path, unless a parser writes it
+		key := k
+		if c.root != "" {
+			key = c.root
+		}
+		if !isNormalizedKey(key, km) {
+			p.needles = append(p.needles, textPieces(key, "")...)
+		}
+	}
+	return p
+}
+
+// Reject reports whether the record started by the line, read with the given key mapping, surely doesn't
+// pass the filter.
+// it is safe to use from several goroutines.
+func (p *Prefilter) Reject(line []byte, km *KeyMapping) bool {
+	if i := firstNonSpace(line); i == len(line) || line[i] != '{' {
+		return false
+	}
+	for _, n := range p.needles {
+		if !bytes.Contains(line, n) {
+			// whatever encoder wrote the line, it can't pass if it is a log at all
+			return scanMembers(line, checkMember) == nil
+		}
+	}
+
+	km = km.orDefault()
+	var (
+		level      = rawField{keys: km.Level}
+		ts         = rawField{keys: km.Time}
+		caller     = rawField{keys: km.Caller}
+		name       = rawField{keys: km.Name}
+		plainLevel = rawField{keys: plainLevelKeys}
+		plainTime  = rawField{keys: plainTimeKeys}
+		source     = rawField{keys: sourceKeys}
+		file       = rawField{keys: fileKeys}
+		function   = rawField{keys: funcKeys}
+		fields     = [...]*rawField{&level, &ts, &caller, &name, &plainLevel, &plainTime, &source, &file, &function}
+	)
+	if err := scanMembers(line, func(key, value []byte) error {
+		key, ok := memberKey(key)
+		if !ok {
+			return errUnexpectedToken
+		}
+		for _, f := range fields {
+			f.observe(key, value)
+		}
+		return nil
+	}); err != nil {
+		return false
+	}
+
+	// the fields of logs of other encoders are moved before they're filtered, see detectJSONFormat
+	shape := jsonShape{
+		level:    string(plainLevel.value),
+		time:     plainTime.rank > 0,
+		source:   rawKind(source.value),
+		file:     file.rank > 0,
+		function: function.rank > 0,
+	}
+	if shape.format() != "" || ts.rank == 0 && plainTime.rank > 0 && isRawString(plainLevel.value) {
+		return false
+	}
+
+	if l, ok := rawText(level.value); ok && !levelPasses(string(l), p.f) {
+		return true
+	}
+	if p.f.Caller != "" {
+		if c, ok := rawText(caller.value); ok && !bytes.Contains(c, p.caller) {
+			return true
+		}
+	}
+	if len(p.f.Logger) > 0 {
+		if n, ok := rawText(name.value); ok && (len(n) == 0 || !matchLogger(string(n), p.f.Logger)) {
+			return true
+		}
+	}
+	if !p.f.Since.IsZero() || !p.f.Until.IsZero() {
+		if t, err := timeOf(string(ts.value)); err != nil || !inTimeRange(t, p.f) {
+			return true
+		}
+	}
+	for _, c := range p.meta {
+		if c.reject(line, km) {
+			return true
+		}
+	}
+	return false
+}
+
+// keys read by the detection of the format of JSON lines
+var (
+	plainLevelKeys = []string{"level"}
+	plainTimeKeys  = []string{"time"}
+	sourceKeys     = []string{"source"}
+	fileKeys       = []string{"file"}
+	funcKeys       = []string{"func"}
+)
+
+// reject reports whether the line surely doesn't have the pair.
+func (c metaCheck) reject(line []byte, km *KeyMapping) bool {
+	// continuation lines of a record are attached to its stacktrace
+	if containsKey(km.Stacktrace, c.key) || containsKey(km.Stacktrace, c.root) {
+		return false
+	}
+
+	literal, root := rawField{keys: []string{c.key}}, rawField{keys: []string{c.root}}
+	scanMembers(line, func(key, value []byte) error {
+		key, _ = memberKey(key)
+		literal.observe(key, value)
+		root.observe(key, value)
+		return nil
+	})
+
+	if literal.rank > 0 {
+		equal, sure := rawEqual(literal.value, c.want)
+		return sure && !equal
+	}
+	return c.root == "" || root.rank == 0
+}
+
+// rawField represents a field of a raw line, read from the first of its keys the line has.
+type rawField struct {
+	keys  []string
+	value []byte
+	rank  int // position of the key the value was read from plus one, 0 if the line has none of the keys
+}
+
+// observe reads the value of the member if its key is one of the keys of the field,
+// not placed after the one the field was read from.
+func (f *rawField) observe(key, value []byte) {
+	for i, k := range f.keys {
+		if f.rank > 0 && i >= f.rank {
+			return
+		}
+		if string(key) == k {
+			f.value, f.rank = value, i+1
+			return
+		}
+	}
+}
+
+// memberKey decodes the quoted key of a member, without copying it if it has no escape sequences.
+func memberKey(quoted []byte) ([]byte, bool) {
+	if bytes.IndexByte(quoted, '\\') < 0 {
+		return quoted[1 : len(quoted)-1], true
+	}
+	k, ok := unquote(quoted)
+	return []byte(k), ok
+}
+
+// checkMember fails on members whose keys can't be decoded, like scanObject does.
+func checkMember(key, _ []byte) error {
+	if _, ok := memberKey(key); !ok {
+		return errUnexpectedToken
+	}
+	return nil
+}
+
+// rawText returns the text of a raw value like Value.String, without decoding it.
+// strings with escape sequences can't be read this way.
+func rawText(value []byte) ([]byte, bool) {
+	if !isRawString(value) {
+		return value, true
+	}
+	s := value[1 : len(value)-1]
+	return s, bytes.IndexByte(s, '\\') < 0
+}
+
+func isRawString(value []byte) bool {
+	return len(value) > 0 && value[0] == '"'
+}
+
+// rawKind returns the kind of a raw value.
+func rawKind(value []byte) Kind {
+	if len(value) == 0 {
+		return InvalidKind
+	}
+	switch value[0] {
+	case '"':
+		return StringKind
+	case '{':
+		return ObjectKind
+	case '[':
+		return ArrayKind
+	case 't', 'f':
+		return BoolKind
+	case 'n':
+		return NullKind
+	}
+	return NumberKind
+}
+
+// rawEqual compares a raw value with a value like Value.Equal, without decoding it.
+// the second result is false when it can't tell, as for escaped strings, objects and arrays.
+func rawEqual(value []byte, want Value) (bool, bool) {
+	if rawKind(value) != want.kind {
+		return false, true
+	}
+
+	switch want.kind {
+	case StringKind:
+		s, ok := rawText(value)
+		return ok && string(s) == want.str, ok
+	case NumberKind:
+		n, err := strconv.ParseFloat(string(value), 64)
+		return err == nil && n == want.num, err == nil
+	case BoolKind, NullKind:
+		return string(value) == want.raw, true
+	}
+	return false, false
+}
+
+// textPieces splits the text into the pieces that JSON encoders write as they are, and that parsers
+// reading callers from file paths and line numbers keep, so a line having the text contains each of them.
+// the text is split at the given separators too.
+func textPieces(text, separators string) [][]byte {
+	var pieces [][]byte
+	start := 0
+	for i := 0; i <= len(text); i++ {
+		if i < len(text) && isPlainByte(text[i]) && strings.IndexByte(separators, text[i]) < 0 {
+			continue
+		}
+		if i > start {
+			pieces = append(pieces, []byte(text[start:i]))
+		}
+		start = i + 1
+	}
+	return pieces
+}
+
+// isPlainByte reports whether the byte is written as it is by JSON encoders.
+// path separators and colons are left out too, as callers are rebuilt from their parts by some parsers.
+func isPlainByte(c byte) bool {
+	switch c {
+	case '"', '\\', '<', '>', '&', '/', ':':
+		return false
+	}
+	return c >= ' ' && c < 0x7f
+}
+
+// isNormalizedKey reports whether the key can be written by a parser instead of being in the line,
+// like the keys of the core fields of logs of other encoders moved to the keys of the key mapping.
+func isNormalizedKey(key string, km *KeyMapping) bool {
+	for _, m := range []*KeyMapping{&keyCandidates, km} {
+		for _, keys := range [][]string{m.Level, m.Time, m.Caller, m.Message, m.Name, m.Stacktrace} {
+			if containsKey(keys, key) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package prettierzap
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hadisinaee/pz/query"
+)
+
+func TestPrefilterReject(t *testing.T) {
+	bob, status, nested, stack := "bob", "200", "500", "boom"
+	since := time.Unix(1522426145, 0)
+	custom := DefaultKeyMapping().Override(KeyMapping{Level: []string{"severity"}, Message: []string{"text"}})
+
+	testScenarios := []struct {
+		Name     string
+		Filter   LogFilter
+		Line     string
+		Keys     *KeyMapping
+		Rejected bool
+	}{
+		{"fails - level rejected", LogFilter{Level: "error"}, `{"level":"info","ts":1,"msg":"a"}`, nil, true},
+		{"pass - level passed", LogFilter{Level: "warn,error"}, `{"level":"error","ts":1,"msg":"a"}`, nil, false},
+		{"pass - level alias", LogFilter{Level: "warn"}, `{"level":"WARNING","ts":1,"msg":"a"}`, nil, false},
+		{"fails - min level", LogFilter{MinLevel: "warn"}, `{"level":"debug","ts":1,"msg":"a"}`, nil, true},
+		{"fails - missing level", LogFilter{MinLevel: "warn"}, `{"ts":1,"msg":"a"}`, nil, true},
+		{"fails - caller rejected", LogFilter{Caller: "server.go"}, `{"level":"info","ts":1,"caller":"http/handler.go:10"}`, nil, true},
+		{"pass - caller passed", LogFilter{Caller: "http/server"}, `{"level":"info","ts":1,"caller":"http/server.go:10"}`, nil, false},
+		{"pass - escaped caller", LogFilter{Caller: "http/server"}, `{"level":"info","ts":1,"caller":"http\/server.go:10"}`, nil, false},
+		{"fails - logger rejected", LogFilter{Logger: []string{"db.*"}}, `{"level":"info","ts":1,"logger":"api.http"}`, nil, true},
+		{"pass - logger passed", LogFilter{Logger: []string{"db.*"}}, `{"level":"info","ts":1,"logger":"db.sql"}`, nil, false},
+		{"fails - logger missing", LogFilter{Logger: []string{"*"}}, `{"level":"info","ts":1}`, nil, true},
+		{"fails - since rejected", LogFilter{Since: since}, `{"level":"info","ts":1522426144}`, nil, true},
+		{"pass - since passed", LogFilter{Since: since}, `{"level":"info","ts":1522426145.5}`, nil, false},
+		{"fails - meta rejected", LogFilter{Meta: map[string]*string{"user": &bob}}, `{"level":"info","ts":1,"user":"alice"}`, nil, true},
+		{"fails - meta missing", LogFilter{Meta: map[string]*string{"user": &bob}}, `{"level":"info","ts":1,"name":"bob"}`, nil, true},
+		{"pass - meta passed", LogFilter{Meta: map[string]*string{"user": &bob}}, `{"level":"info","ts":1,"user":"bob"}`, nil, false},
+		{"pass - meta escaped", LogFilter{Meta: map[string]*string{"user": &bob}}, `{"level":"info","ts":1,"user":"b\u006fb"}`, nil, false},
+		{"pass - meta number", LogFilter{Meta: map[string]*string{"status": &status}}, `{"level":"info","ts":1,"status":200.0}`, nil, false},
+		{"fails - meta kind", LogFilter{Meta: map[string]*string{"status": &status}}, `{"level":"info","ts":1,"status":"200"}`, nil, true},
+		{"pass - meta nested", LogFilter{Meta: map[string]*string{"http.status": &nested}}, `{"level":"info","ts":1,"http":{"status":200}}`, nil, false},
+		{"pass - meta stacktrace", LogFilter{Meta: map[string]*string{"stacktrace": &stack}}, `{"level":"info","ts":1}`, nil, false},
+		{"pass - plain text", LogFilter{Level: "error"}, `plain line`, nil, false},
+		{"pass - invalid JSON", LogFilter{Level: "error"}, `{'level': 'info'}`, nil, false},
+		{"pass - invalid JSON without needle", LogFilter{Caller: "server.go"}, `{caller: handler.go}`, nil, false},
+		{"pass - other encoder", LogFilter{Level: "error"}, `{"time":"2018-03-30T16:09:05Z","level":"INFO","msg":"a"}`, nil, false},
+		{"pass - level of the custom key", LogFilter{Level: "error"}, `{"level":"info","severity":"error","ts":1,"text":"a"}`, &custom, false},
+		{"fails - level of the custom key", LogFilter{Level: "error"}, `{"level":"error","severity":"info","ts":1,"text":"a"}`, &custom, true},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := NewPrefilter(tc.Filter, tc.Keys)
+			if p == nil {
+				t.Fatalf("NewPrefilter: expected a prefilter for %+v", tc.Filter)
+			}
+			if got := p.Reject([]byte(tc.Line), tc.Keys); got != tc.Rejected {
+				t.Errorf("Reject: expected %v received: %v", tc.Rejected, got)
+			}
+		})
+	}
+
+	q, _ := query.Parse(`status >= 500`)
+	if p := NewPrefilter(LogFilter{Query: q}, nil); p != nil {
+		t.Errorf("NewPrefilter: expected no prefilter for a query")
+	}
+}
+
+func TestPrefilterRecords(t *testing.T) {
+	corpus := testCorpus(3000)
+	corpus = append(corpus,
+		`{"time":"2018-03-30T16:09:05Z","level":"ERROR","msg":"slog","source":{"file":"/src/http/server.go","line":12}}`,
+		`{"level":"error","ts":1522426200,"caller":"http\/server.go:40","msg":"escaped"}`,
+		`{"level":"error","ts":1522426200,"msg":"dump"}`,
+		"goroutine 1 [running]:",
+		"{not json}",
+		`{"level":"info","severity":"error","ts":1522426200,"text":"custom key"}`,
+	)
+
+	user, status := "1200", "200"
+	custom := DefaultKeyMapping().Override(KeyMapping{Level: []string{"severity"}, Message: []string{"text"}})
+	testScenarios := []struct {
+		Name   string
+		Filter LogFilter
+		Keys   *KeyMapping
+	}{
+		{"pass - level", LogFilter{Level: "error"}, nil},
+		{"pass - min level and caller", LogFilter{MinLevel: "warn", Caller: "server.go:43"}, nil},
+		{"pass - logger and level", LogFilter{Logger: []string{"api.*"}, Level: "warn"}, nil},
+		{"pass - time range", LogFilter{Since: time.Unix(1522426146, 500e6), Until: time.Unix(1522426147, 0)}, nil},
+		{"pass - nested meta", LogFilter{Meta: map[string]*string{"user.id": &user}}, nil},
+		{"pass - meta and level", LogFilter{Meta: map[string]*string{"status": &status}, Level: "info"}, nil},
+		{"pass - level of a custom key", LogFilter{Level: "error"}, &custom},
+	}
+
+	read := func(f LogFilter, km *KeyMapping, prefilter bool, multiline bool, workers int) []string {
+		lines := linesOf(append([]string(nil), corpus...))
+		r := NewLogReader(&lines, keyedParser(parseAuto))
+		r.Keys = km
+		if multiline {
+			r.Assembler = NewAssembler(r.Parser)
+		}
+		if prefilter {
+			r.Prefilter = NewPrefilter(f, km)
+		}
+		r.Workers = workers
+
+		var msgs []string
+		for {
+			pj, err := r.Next()
+			if err == io.EOF {
+				return msgs
+			}
+			if err != nil {
+				t.Fatalf("Next: expected no error received: %v", err)
+			}
+			if filterJSON(pj, f) {
+				st, _ := pj.GetValue("stacktrace")
+				msgs = append(msgs, msgOf(pj)+"|"+st.String())
+			}
+		}
+	}
+
+	for _, tc := range testScenarios {
+		for _, multiline := range []bool{false, true} {
+			for _, workers := range []int{1, 4} {
+				t.Run(fmt.Sprintf("%s multiline %v workers %d", tc.Name, multiline, workers), func(t *testing.T) {
+					want := read(tc.Filter, tc.Keys, false, multiline, workers)
+					got := read(tc.Filter, tc.Keys, true, multiline, workers)
+					if len(want) == 0 {
+						t.Fatalf("checkCorpus: expected some records to pass the filter")
+					}
+					if strings.Join(got, "\n") != strings.Join(want, "\n") {
+						t.Errorf("checkRecords: expected the records filtered without the prefilter, received %d records instead of %d", len(got), len(want))
+					}
+					if tc.Keys != nil && !strings.Contains(strings.Join(got, "\n"), "custom key|") {
+						t.Errorf("checkKeys: expected the record with the level of the custom key received: %v", got)
+					}
+				})
+			}
+		}
+	}
+}
+
+func benchmarkPrefilter(b *testing.B, f LogFilter, line string) {
+	p := NewPrefilter(f, nil)
+	l := []byte(line)
+	b.SetBytes(int64(len(l)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !p.Reject(l, nil) {
+			b.Fatalf("Reject: expected the line to be rejected")
+		}
+	}
+}
+
+func BenchmarkPrefilterLevel(b *testing.B) {
+	benchmarkPrefilter(b, LogFilter{Level: "error"}, testCorpus(1)[0])
+}
+
+func BenchmarkPrefilterCaller(b *testing.B) {
+	benchmarkPrefilter(b, LogFilter{Caller: "db/"}, testCorpus(1)[0])
+}
+
+func BenchmarkPrefilterMeta(b *testing.B) {
+	status := "500"
+	benchmarkPrefilter(b, LogFilter{Meta: map[string]*string{"status": &status}}, testCorpus(1)[0])
+}
+
+func BenchmarkPipelinePrefilteredSequential(b *testing.B) {
+	benchmarkPipeline(b, 1, LogFilter{Level: "error"}, true)
+}
+
+func BenchmarkPipelinePrefilteredParallel(b *testing.B) {
+	benchmarkPipeline(b, runtime.NumCPU(), LogFilter{Level: "error"}, true)
+}
+
+// rareFilter lets one line of the test corpus out of 28 pass.
+var rareFilter = LogFilter{Level: "error", Caller: "server.go:40"}
+
+func BenchmarkPipelineRareFiltered(b *testing.B) {
+	benchmarkPipeline(b, 1, rareFilter, false)
+}
+
+func BenchmarkPipelineRarePrefiltered(b *testing.B) {
+	benchmarkPipeline(b, 1, rareFilter, true)
+}
@@ -0,0 +1,226 @@
+package prettierzap
+
+import (
+	"bytes"
+	"path"
+	"strings"
+)
+
+// ParseFieldPatterns splits comma separated patterns of meta fields like `user,http.*`.
+// patterns are dotted paths of nested fields, each of their segments uses the syntax of path.Match.
+func ParseFieldPatterns(s string) ([]string, error) {
+	var patterns []string
+	for _, p := range strings.Split(s, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, err := path.Match(p, ""); err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns, nil
+}
+
+// fieldPattern represents a pattern of meta fields split into its segments.
+type fieldPattern struct {
+	text     string
+	segments []string
+}
+
+// splitPatterns splits the patterns into their segments.
+func splitPatterns(patterns []string) []fieldPattern {
+	fps := make([]fieldPattern, len(patterns))
+	for i, p := range patterns {
+		fps[i] = fieldPattern{p, strings.Split(p, ".")}
+	}
+	return fps
+}
+
+// matchField returns whether a pattern matches the field with the given key as a whole, and the rest of
+// the patterns matching the fields nested in it.
+// keys with dots, like the ones of logfmt lines, are matched like paths: `http` and `http.*` match `http.status`.
+func matchField(key string, patterns []fieldPattern) (bool, []fieldPattern) {
+	var nested []fieldPattern
+	for _, p := range patterns {
+		if matchDotted(p.text, key) {
+			return true, nil
+		}
+		if ok, _ := path.Match(p.segments[0], key); !ok {
+			continue
+		}
+		if len(p.segments) == 1 {
+			return true, nil
+		}
+		nested = append(nested, fieldPattern{strings.Join(p.segments[1:], "."), p.segments[1:]})
+	}
+	return false, nested
+}
+
+// matchDotted reports whether the pattern matches the key, or the part of a dotted key before one of its dots.
+func matchDotted(pattern, key string) bool {
+	for {
+		if ok, _ := path.Match(pattern, key); ok {
+			return true
+		}
+		i := strings.LastIndexByte(key, '.')
+		if i < 0 {
+			return false
+		}
+		key = key[:i]
+	}
+}
+
+// projectMeta removes the meta fields the printer doesn't show: the ones not matching its Fields patterns,
+// if it has any, and the ones matching its Hide patterns. nested fields are projected too, so `http.status`
+// keeps just the status of the http object.
+// the stacktrace is kept unless it is hidden.
+func (p *Printer) projectMeta(meta map[string]string, km *KeyMapping) {
+	if len(p.Fields) > 0 {
+		stKey := km.stacktraceKey(meta)
+		include := splitPatterns(p.Fields)
+		for k, raw := range meta {
+			if k == stKey {
+				continue
+			}
+			if projected, ok := includeField(k, raw, include); ok {
+				meta[k] = projected
+			} else {
+				delete(meta, k)
+			}
+		}
+	}
+	if len(p.Hide) > 0 {
+		hide := splitPatterns(p.Hide)
+		for k, raw := range meta {
+			if projected, ok := hideField(k, raw, hide); ok {
+				meta[k] = projected
+			} else {
+				delete(meta, k)
+			}
+		}
+	}
+}
+
+// includeField returns the value of the field with just its parts matching the patterns,
+// or false if none of them matches.
+func includeField(key, raw string, patterns []fieldPattern) (string, bool) {
+	whole, nested := matchField(key, patterns)
+	if whole {
+		return raw, true
+	}
+	if len(nested) == 0 {
+		return "", false
+	}
+	projected, n := projectObject(raw, func(k, v string) (string, bool) { return includeField(k, v, nested) })
+	return projected, n > 0
+}
+
+// hideField returns the value of the field without its parts matching the patterns,
+// or false if the whole field matches, or all of its parts do.
+func hideField(key, raw string, patterns []fieldPattern) (string, bool) {
+	whole, nested := matchField(key, patterns)
+	if whole {
+		return "", false
+	}
+	if len(nested) == 0 {
+		return raw, true
+	}
+	projected, n := projectObject(raw, func(k, v string) (string, bool) { return hideField(k, v, nested) })
+	if n < 0 {
+		return raw, true
+	}
+	return projected, n > 0
+}
+
+// projectObject rebuilds the JSON object with the members the function keeps, in their order,
+// and returns the number of members kept, or -1 if the value isn't an object.
+func projectObject(raw string, fn func(key, value string) (string, bool)) (string, int) {
+	if valueOf(raw).kind != ObjectKind {
+		return "", -1
+	}
+	var b bytes.Buffer
+	b.WriteByte('{')
+	n := 0
+	scanObject([]byte(strings.TrimSpace(raw)), func(key string, value []byte) {
+		v, ok := fn(key, string(value))
+		if !ok {
+			return
+		}
+		if n > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(jsonQuote(key))
+		b.WriteByte(':')
+		b.WriteString(v)
+		n++
+	})
+	b.WriteByte('}')
+	return b.String(), n
+}
+
+// meta returns the meta fields of the record the printer shows.
+func (p *Printer) meta(pj ParsedJSON) map[string]string {
+	meta := pj.GetMeta()
+	p.projectMeta(meta, mappingOf(pj))
+	return meta
+}
+
+// projectLine removes the meta fields the printer doesn't show from the JSON line of the record,
+// leaving the other members as they are, with their spacing and in their order.
+func (p *Printer) projectLine(pj ParsedJSON, line string) string {
+	meta, shown := pj.GetMeta(), p.meta(pj)
+	data := []byte(line)
+	// the keys and values are slices of data, their offsets are found from their capacities
+	offset := func(s []byte) int { return cap(data) - cap(s) }
+
+	var b bytes.Buffer
+	prev, n := -1, 0 // end of the previous member, number of members kept
+	scanMembers(data, func(quoted, value []byte) error {
+		start, end := offset(quoted), offset(value)+len(value)
+		var sep []byte // the comma before the member, with its spacing
+		if prev < 0 {
+			b.Write(data[:start])
+		} else {
+			sep = data[prev:start]
+		}
+		prev = end
+
+		key, _ := memberKey(quoted)
+		raw, isMeta := meta[string(key)]
+		projected, ok := shown[string(key)]
+		if isMeta && !ok {
+			return nil
+		}
+		if n > 0 {
+			b.Write(sep)
+		}
+		if isMeta && projected != raw {
+			b.Write(data[start:offset(value)])
+			b.WriteString(projected)
+		} else {
+			b.Write(data[start:end])
+		}
+		n++
+		return nil
+	})
+	if prev < 0 {
+		return line
+	}
+	b.Write(data[prev:])
+	return b.String()
+}
+
+// isMetaPath reports whether the field path is in one of the meta fields.
+func isMetaPath(meta map[string]string, path string) bool {
+	if _, ok := meta[path]; ok {
+		return true
+	}
+	segments, err := parsePath(path)
+	if err != nil || len(segments) == 0 || segments[0].isIndex {
+		return false
+	}
+	_, ok := meta[segments[0].key]
+	return ok
+}
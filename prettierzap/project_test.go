@@ -0,0 +1,74 @@
+package prettierzap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestProjectMeta(t *testing.T) {
+	line := `{"level":"error","msg":"a","hostname":"h","pid":1,"user_id":3,"http":{"status":500,"method":"GET"},"build":{"sha":"abc","ver":1},"db.query":"q","stacktrace":"main.main"}`
+	pj, _ := ParseJSONByteArray([]byte(line))
+
+	testScenarios := []struct {
+		Name   string
+		Fields []string
+		Hide   []string
+		Wanted map[string]string
+	}{
+		{"pass - all fields", nil, nil, pj.GetMeta()},
+		{"pass - fields", []string{"user_id", "pid"}, nil, map[string]string{"pid": "1", "user_id": "3", "stacktrace": `"main.main"`}},
+		{"pass - nested fields", []string{"http.status", "build.*"}, nil, map[string]string{"http": `{"status":500}`, "build": `{"sha":"abc","ver":1}`, "stacktrace": `"main.main"`}},
+		{"pass - globs", []string{"h*", "db.*"}, nil, map[string]string{"hostname": `"h"`, "http": `{"status":500,"method":"GET"}`, "db.query": `"q"`, "stacktrace": `"main.main"`}},
+		{"pass - hidden fields", nil, []string{"hostname", "pid", "build.sha", "db", "stacktrace"}, map[string]string{"user_id": "3", "http": `{"status":500,"method":"GET"}`, "build": `{"ver":1}`}},
+		{"pass - hidden nested fields", nil, []string{"http.*"}, map[string]string{"hostname": `"h"`, "pid": "1", "user_id": "3", "build": `{"sha":"abc","ver":1}`, "db.query": `"q"`, "stacktrace": `"main.main"`}},
+		{"pass - fields and hidden fields", []string{"http", "build"}, []string{"http.method", "build"}, map[string]string{"http": `{"status":500}`, "stacktrace": `"main.main"`}},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := NewPrinter()
+			p.Fields = tc.Fields
+			p.Hide = tc.Hide
+			if got := p.meta(pj); !reflect.DeepEqual(got, tc.Wanted) {
+				t.Errorf("meta: expected %v received: %v", tc.Wanted, got)
+			}
+		})
+	}
+}
+
+func TestRenderProjected(t *testing.T) {
+	line := `{ "ts": 1585138003.0963275, "level": "info", "msg": "a", "pid": 1, "user": {"id": 1, "name": "x"} }`
+	pj, _ := ParseJSONByteArray([]byte(line))
+
+	p := NewPrinter()
+	p.Output = OutputJSON
+	p.Hide = []string{"pid", "user.name"}
+	if got, want := p.renderJSON(withLine(pj, line)), `{ "ts": 1585138003.0963275, "level": "info", "msg": "a", "user": {"id":1} }`+"\n"; got != want {
+		t.Errorf("checkJSON: expected the line without the hidden fields %s received: %s", want, got)
+	}
+
+	p.Hide = []string{"user.*"}
+	if got, want := p.renderJSON(withLine(pj, line)), `{ "ts": 1585138003.0963275, "level": "info", "msg": "a", "pid": 1 }`+"\n"; got != want {
+		t.Errorf("checkEmptyParent: expected the line without the emptied object %s received: %s", want, got)
+	}
+
+	p.Output = OutputCSV
+	p.Columns = []string{"msg", "pid", "user.id"}
+	p.Hide = []string{"user"}
+	if got, _ := p.render(withLine(pj, line)); got != "a,1,\n" {
+		t.Errorf("checkColumns: expected the hidden columns empty received: %q", got)
+	}
+}
+
+func TestParseFieldPatterns(t *testing.T) {
+	patterns, err := ParseFieldPatterns(" user_id, http.*,,")
+	if err != nil {
+		t.Fatalf("ParseFieldPatterns: expected no error received: %v", err)
+	}
+	if want := []string{"user_id", "http.*"}; !reflect.DeepEqual(patterns, want) {
+		t.Errorf("ParseFieldPatterns: expected %v received: %v", want, patterns)
+	}
+	if _, err := ParseFieldPatterns("http.[a"); err == nil {
+		t.Errorf("ParseFieldPatterns: expected an error for an invalid pattern")
+	}
+}
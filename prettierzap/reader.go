@@ -0,0 +1,300 @@
+package prettierzap
+
+import (
+	"container/heap"
+	"io"
+	"time"
+)
+
+// LineSource represents a source of lines, like input.LineReader.
+// ReadLine returns the next line and the number of bytes dropped from it, or io.EOF at the end.
+// errors with a Temporary method returning true, like the one of a followed file waiting for new
+// lines, don't end the source.
+type LineSource interface {
+	ReadLine() ([]byte, int, error)
+}
+
+// LogReader reads the records of a source of lines.
+type LogReader struct {
+	Source    string       // name of the source added to the records, like the name of the file, if set
+	Parser    Parser       // parser of the lines
+	Assembler *Assembler   // assembler of multi-line records, each line is a record if nil
+	Keys      *KeyMapping  // keys of the core fields of the lines, the default ones if nil
+	Detector  *KeyDetector // detector of the key mapping fed with the lines, used instead of Keys if set
+	Prefilter *Prefilter   // rejects the lines of records that can't pass the filter before they're parsed, if set
+	KeepLines bool         // label the records with their lines, see LineOf, the assembler keeps them if its KeepLines is set
+	Workers   int          // goroutines parsing the lines, the lines are parsed by Next if less than 2
+
+	lines LineSource
+	done  bool
+
+	// state of the parallel parsing
+	batches    chan *lineBatch
+	batch      *lineBatch
+	index      int
+	pendingErr error
+}
+
+// NewLogReader returns a reader of the records of the lines, parsed with the given parser.
+func NewLogReader(lines LineSource, p Parser) *LogReader {
+	return &LogReader{
+		Parser: p,
+		lines:  lines,
+	}
+}
+
+// Next returns the next record, or io.EOF at the end of the source.
+// temporary errors of the source, like the ones of a followed file waiting for new lines,
+// are returned as they are, and the reader can be read on.
+func (r *LogReader) Next() (ParsedJSON, error) {
+	if r.Workers > 1 {
+		return r.nextParallel()
+	}
+
+	for !r.done {
+		l, dropped, err := r.lines.ReadLine()
+		if isTemporary(err) {
+			// the source waits for new lines: the record being assembled is complete
+			if r.Assembler != nil {
+				if pj, ok := r.Assembler.Flush(); ok {
+					return r.withSource(pj), nil
+				}
+			}
+			return nil, err
+		}
+		if err != nil {
+			r.done = true
+			if err != io.EOF {
+				return nil, err
+			}
+			break
+		}
+
+		km := r.keysOf(l)
+
+		var (
+			pj ParsedJSON
+			ok bool
+		)
+		switch {
+		case r.rejects(l, dropped, km):
+			if r.Assembler != nil {
+				pj, ok = r.Assembler.addRejected()
+			}
+		case r.Assembler != nil:
+			pj, ok = r.Assembler.add(l, dropped, km)
+		default:
+			pj, ok = r.parseLine(l, dropped, km)
+		}
+		if ok {
+			return r.withSource(pj), nil
+		}
+	}
+
+	if r.Assembler != nil {
+		if pj, ok := r.Assembler.Flush(); ok {
+			return r.withSource(pj), nil
+		}
+	}
+	return nil, io.EOF
+}
+
+// keysOf returns the key mapping to read the line with, feeding the detector with it if it is set.
+func (r *LogReader) keysOf(line []byte) *KeyMapping {
+	if r.Detector != nil {
+		return r.Detector.Observe(line)
+	}
+	return r.Keys.orDefault()
+}
+
+// parseLine parses a line that is a record of its own.
+func (r *LogReader) parseLine(line []byte, dropped int, km *KeyMapping) (ParsedJSON, bool) {
+	pj, ok := parseTruncatedLine(r.Parser, line, dropped, km)
+	if ok && r.KeepLines {
+		pj = withLine(pj, string(line))
+	}
+	return pj, ok
+}
+
+// rejects reports whether the line starts a record that the prefilter rejects.
+// truncated lines are left to the parser, which salvages what it can of them.
+func (r *LogReader) rejects(line []byte, dropped int, km *KeyMapping) bool {
+	if r.Prefilter == nil || dropped > 0 {
+		return false
+	}
+	if r.Assembler != nil && r.Assembler.Start != nil && !r.Assembler.Start.Match(line) {
+		return false
+	}
+	return r.Prefilter.Reject(line, km)
+}
+
+// isTemporary reports whether the error is temporary.
+func isTemporary(err error) bool {
+	te, ok := err.(interface {
+		Temporary() bool
+	})
+	return ok && te.Temporary()
+}
+
+func (r *LogReader) withSource(pj ParsedJSON) ParsedJSON {
+	if r.Source == "" {
+		return pj
+	}
+	return WithSource(pj, r.Source)
+}
+
+// sourcedLog represents a record labelled with where it was read from.
+type sourcedLog struct {
+	ParsedJSON
+	source string // name of the source, if labelled
+	line   string // original text of the record, if kept
+}
+
+// labelsOf returns the labels of the record, to add some.
+func labelsOf(pj ParsedJSON) sourcedLog {
+	if s, ok := pj.(sourcedLog); ok {
+		return s
+	}
+	return sourcedLog{ParsedJSON: pj}
+}
+
+// WithSource labels the record with the name of the source it was read from.
+func WithSource(pj ParsedJSON, source string) ParsedJSON {
+	s := labelsOf(pj)
+	s.source = source
+	return s
+}
+
+// SourceOf returns the name of the source of the record, if it is labelled.
+func SourceOf(pj ParsedJSON) string {
+	if s, ok := pj.(sourcedLog); ok {
+		return s.source
+	}
+	return ""
+}
+
+// withLine labels the record with its original text.
+func withLine(pj ParsedJSON, line string) ParsedJSON {
+	s := labelsOf(pj)
+	s.line = line
+	return s
+}
+
+// LineOf returns the original text of the record, its lines joined by newlines, if it was kept.
+func LineOf(pj ParsedJSON) (string, bool) {
+	if s, ok := pj.(sourcedLog); ok && s.line != "" {
+		return s.line, true
+	}
+	return "", false
+}
+
+// unwrapLog returns the record without its labels.
+func unwrapLog(pj ParsedJSON) ParsedJSON {
+	if s, ok := pj.(sourcedLog); ok {
+		return s.ParsedJSON
+	}
+	return pj
+}
+
+// MergeReader merges the records of several readers in the order of their times.
+// the readers are expected to be ordered by time themselves, as log files are.
+// records without a readable time keep their place after the record before them in their source.
+type MergeReader struct {
+	heap mergeHeap
+	init bool
+}
+
+// NewMergeReader returns a reader merging the records of the given readers.
+func NewMergeReader(readers ...*LogReader) *MergeReader {
+	m := &MergeReader{}
+	for i, r := range readers {
+		m.heap = append(m.heap, &mergeItem{reader: r, index: i})
+	}
+	return m
+}
+
+// Next returns the next record in the order of time, or io.EOF when all readers are done.
+func (m *MergeReader) Next() (ParsedJSON, error) {
+	if !m.init {
+		m.init = true
+		items := m.heap[:0]
+		for _, it := range m.heap {
+			if err := it.advance(); err != nil && err != io.EOF {
+				return nil, err
+			} else if err == nil {
+				items = append(items, it)
+			}
+		}
+		m.heap = items
+		heap.Init(&m.heap)
+	}
+
+	if len(m.heap) == 0 {
+		return nil, io.EOF
+	}
+
+	it := m.heap[0]
+	pj := it.next
+	if err := it.advance(); err == nil {
+		heap.Fix(&m.heap, 0)
+	} else {
+		heap.Pop(&m.heap)
+		if err != io.EOF {
+			return pj, err
+		}
+	}
+	return pj, nil
+}
+
+// mergeItem represents a reader of a merge with its next record.
+type mergeItem struct {
+	reader *LogReader
+	index  int // position of the reader, to keep the order of sources for records of the same time
+	next   ParsedJSON
+	time   time.Time
+}
+
+// advance reads the next record of the reader.
+func (it *mergeItem) advance() error {
+	pj, err := it.reader.Next()
+	if err != nil {
+		return err
+	}
+	it.next = pj
+	if t, errTime := pj.GetTime(); errTime == nil && hasOwnTime(pj) {
+		it.time = t
+	}
+	return nil
+}
+
+// hasOwnTime reports whether the time of the record was written by its logger,
+// unlike lines of plain text and dumps, which get the time they're read at.
+func hasOwnTime(pj ParsedJSON) bool {
+	if _, ok := unwrapLog(pj).(dumper); ok {
+		return false
+	}
+	return callerOf(pj) != textCaller
+}
+
+// mergeHeap orders the readers of a merge by the time of their next record.
+type mergeHeap []*mergeItem
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	if h[i].time.Equal(h[j].time) {
+		return h[i].index < h[j].index
+	}
+	return h[i].time.Before(h[j].time)
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x interface{}) { *h = append(*h, x.(*mergeItem)) }
+
+func (h *mergeHeap) Pop() interface{} {
+	old := *h
+	it := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return it
+}
@@ -0,0 +1,70 @@
+package prettierzap
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+// linesOf is a source of the lines of a text.
+type linesOf []string
+
+func (l *linesOf) ReadLine() ([]byte, int, error) {
+	if len(*l) == 0 {
+		return nil, 0, io.EOF
+	}
+	line := (*l)[0]
+	*l = (*l)[1:]
+	return []byte(line), 0, nil
+}
+
+func newTestReader(source, text string) *LogReader {
+	lines := linesOf(strings.Split(text, "\n"))
+	r := NewLogReader(&lines, keyedParser(parseAuto))
+	r.Source = source
+	return r
+}
+
+func TestMergeReader(t *testing.T) {
+	a := newTestReader("a.log", `{"level":"info","ts":1,"msg":"a1"}`+"\n"+`{"level":"info","ts":4,"msg":"a4"}`+"\nplain a")
+	b := newTestReader("b.log", `{"level":"info","ts":2,"msg":"b2"}`+"\n"+`{"level":"info","ts":3,"msg":"b3"}`)
+	c := newTestReader("c.log", `{"level":"info","ts":1,"msg":"c1"}`)
+
+	var got []string
+	m := NewMergeReader(a, b, c)
+	for {
+		pj, err := m.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Next: expected no error received: %v", err)
+		}
+		got = append(got, SourceOf(pj)+":"+msgOf(pj))
+	}
+
+	want := "a.log:a1 c.log:c1 b.log:b2 b.log:b3 a.log:a4 a.log:plain a"
+	if strings.Join(got, " ") != want {
+		t.Errorf("checkOrder: expected %v received: %v", want, strings.Join(got, " "))
+	}
+}
+
+func TestWithSource(t *testing.T) {
+	a := NewAssembler(keyedParser(parseAuto))
+	a.Add([]byte("panic: boom"))
+	a.Add([]byte("goroutine 1 [running]:"))
+	a.Add([]byte("main.main()"))
+	a.Add([]byte("\t/src/main.go:8 +0x1d"))
+	pj, _ := a.Flush()
+
+	pj = WithSource(WithSource(pj, "old.log"), "app.log")
+	if SourceOf(pj) != "app.log" {
+		t.Errorf("SourceOf: expected the last label received: %v", SourceOf(pj))
+	}
+	if _, ok := unwrapLog(pj).(dumper); !ok {
+		t.Errorf("unwrapLog: expected the dump under the label")
+	}
+	if levelOf(pj) != fatalLevel {
+		t.Errorf("checkLevel: expected the fields of the record received: %v", levelOf(pj))
+	}
+}
@@ -0,0 +1,41 @@
+package prettierzap
+
+// queryRecord adapts a parsed log to the record evaluated by queries.
+// the core fields are available under their zap names whatever key the log used for them.
+type queryRecord struct {
+	pj ParsedJSON
+}
+
+// Field returns the value at the given field path.
+func (r queryRecord) Field(path string) (interface{}, bool) {
+	v, ok := r.value(path)
+	if !ok {
+		return nil, false
+	}
+	return v.Interface(), true
+}
+
+// value returns the decoded value at the given field path.
+func (r queryRecord) value(path string) (Value, bool) {
+	switch path {
+	case "level":
+		return fieldOf(r.pj.GetLevel())
+	case "ts":
+		return fieldOf(r.pj.GetTimestamp())
+	case "caller":
+		return fieldOf(r.pj.GetCaller())
+	case "msg", "message":
+		return fieldOf(r.pj.GetMsg())
+	case "logger":
+		return fieldOf(r.pj.GetName())
+	}
+	return r.pj.Lookup(path)
+}
+
+// fieldOf returns the value of a raw core field, which is missing if empty.
+func fieldOf(raw string) (Value, bool) {
+	if raw == "" {
+		return Value{}, false
+	}
+	return valueOf(raw), true
+}
@@ -0,0 +1,47 @@
+package prettierzap
+
+import (
+	"fmt"
+)
+
+// parseSlog parses a log of the JSON handler of log/slog.
+// the source object written with AddSource is read as the caller.
+func parseSlog(line []byte, km *KeyMapping) (ParsedJSON, error) {
+	lr, err := scanLog(line, km)
+	if err != nil {
+		return nil, err
+	}
+	return lr.withFields(fromSlog(lr.parsedLog, km)), nil
+}
+
+// fromSlog reads the members of a log of log/slog.
+func fromSlog(pl parsedLog, km *KeyMapping) parsedLog {
+	if raw, ok := pl["source"]; ok {
+		source := valueOf(raw)
+		file, _ := source.Get("file")
+		n, _ := source.Get("line")
+		if f, ok := file.AsString(); ok && f != "" {
+			pl["source"] = jsonQuote(fmt.Sprintf("%s:%s", shortCaller(f), n.String()))
+		}
+	}
+	return normalizeKeys(pl, km)
+}
+
+// parseLogrus parses a log of the JSON formatter of logrus.
+// the file written with ReportCaller is read as the caller, the function is kept as a meta field.
+func parseLogrus(line []byte, km *KeyMapping) (ParsedJSON, error) {
+	lr, err := scanLog(line, km)
+	if err != nil {
+		return nil, err
+	}
+	return lr.withFields(fromLogrus(lr.parsedLog, km)), nil
+}
+
+// fromLogrus reads the members of a log of logrus.
+func fromLogrus(pl parsedLog, km *KeyMapping) parsedLog {
+	if file, ok := valueOf(pl["file"]).AsString(); ok && file != "" {
+		delete(pl, "file")
+		pl["caller"] = jsonQuote(shortCaller(file))
+	}
+	return normalizeKeys(pl, km)
+}
@@ -0,0 +1,179 @@
+package prettierzap
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// frameKind represents where the code of a frame comes from.
+type frameKind int
+
+const (
+	appFrame        frameKind = iota // code of the application
+	dependencyFrame                  // code of other modules, vendored or in the module cache
+	stdlibFrame                      // code of the standard library
+	runtimeFrame                     // code of the Go runtime
+)
+
+// ParseStack parses a stacktrace like the ones of zap and debug.Stack into its frames:
+// lines of functions, each one followed by the indented location of the call.
+// the header of the goroutine and the arguments of the functions are dropped.
+// it fails if any other line is left.
+func ParseStack(text string) ([]Frame, bool) {
+	items := scanStack(text)
+	frames := make([]Frame, 0, len(items))
+	for _, it := range items {
+		if !it.isFrame {
+			return nil, false
+		}
+		frames = append(frames, it.frame)
+	}
+	return frames, len(frames) > 0
+}
+
+// stackItem represents a line of a stacktrace: a frame, or a line that isn't part of one.
+type stackItem struct {
+	frame   Frame
+	text    string // the line, if it isn't a frame
+	isFrame bool
+}
+
+// scanStack reads the frames of a stacktrace like ParseStack, keeping the lines that aren't part of
+// a frame, like the text of the logs a stacktrace is followed by, in their place.
+func scanStack(text string) []stackItem {
+	lines := strings.Split(strings.TrimRight(text, "\n"), "\n")
+	if len(lines) > 0 && goroutineHeader.MatchString(strings.TrimSpace(lines[0])) {
+		lines = lines[1:]
+	}
+
+	items := make([]stackItem, 0, len(lines)/2+1)
+	for i := 0; i < len(lines); i++ {
+		fn := strings.TrimRight(lines[i], "\r")
+		if i+1 < len(lines) && fn != "" && fn[0] != ' ' && fn[0] != '\t' {
+			if loc := frameLocation.FindStringSubmatch(strings.TrimRight(lines[i+1], "\r")); loc != nil {
+				f := Frame{Func: trimArgs(fn), File: loc[1]}
+				if m := createdBy.FindStringSubmatch(fn); m != nil {
+					f.Func = "created by " + m[1]
+				}
+				f.Line, _ = strconv.Atoi(loc[2])
+				items = append(items, stackItem{frame: f, isFrame: true})
+				i++
+				continue
+			}
+		}
+		items = append(items, stackItem{text: fn})
+	}
+	return items
+}
+
+// funcPackage returns the import path of the package of a function, like `net/http` for `net/http.(*conn).serve`.
+func funcPackage(fn string) string {
+	fn = strings.TrimPrefix(fn, "created by ")
+	dir, name := "", fn
+	if i := strings.LastIndexByte(fn, '/'); i >= 0 {
+		dir, name = fn[:i+1], fn[i+1:]
+	}
+	if i := strings.IndexByte(name, '.'); i >= 0 {
+		name = name[:i]
+	}
+	return dir + name
+}
+
+// kind returns where the code of the frame comes from.
+// frames of the given module are the application, if it is set, and the frames of any other module
+// out of the module cache and vendor directories are otherwise.
+func (f Frame) kind(module string) frameKind {
+	pkg := funcPackage(f.Func)
+	switch {
+	case pkg == "runtime" || strings.HasPrefix(pkg, "runtime/") || strings.HasPrefix(pkg, "internal/runtime/"):
+		return runtimeFrame
+	case module != "" && (pkg == module || strings.HasPrefix(pkg, module+"/")):
+		return appFrame
+	case pkg == "main":
+		return appFrame
+	case !strings.Contains(strings.SplitN(pkg, "/", 2)[0], "."):
+		return stdlibFrame
+	case module != "" || strings.Contains(f.File, "/pkg/mod/") || strings.Contains(f.File, "/vendor/"):
+		return dependencyFrame
+	}
+	return appFrame
+}
+
+// trimPath removes the prefix of the GOPATH, the GOROOT, the module cache or a vendor directory
+// from the path of a source file, like `github.com/acme/app/server.go` for
+// `/home/user/go/pkg/mod/github.com/acme/app@v1.2.0/server.go`.
+// files of the module out of those directories are read from the package of their function.
+func trimPath(file string, pkg string, module string) string {
+	for _, dir := range []string{"/pkg/mod/", "/vendor/", "/src/"} {
+		if i := strings.Index(file, dir); i >= 0 {
+			return file[i+len(dir):]
+		}
+	}
+	if module != "" && (pkg == module || strings.HasPrefix(pkg, module+"/")) {
+		return path.Join(pkg, path.Base(file))
+	}
+	return file
+}
+
+// writeStacktrace writes a stacktrace field, one frame per line: frames of the application are highlighted
+// and the others dimmed, and just the first MaxFrames frames of the application are written if it is set.
+// lines that aren't part of a frame are written as they are, like stacktraces without any frame.
+func (p *Printer) writeStacktrace(b *bytes.Buffer, key, text string) {
+	theme := p.theme()
+	items := scanStack(text)
+	if !hasFrame(items) {
+		st := strings.Replace(text, "\n\t", "\U0000000A\U00000009\U00000009> ", -1)
+		st = strings.Replace(st, "\n", "\U0000000A\U00000009\U00000009 ", -1)
+		b.WriteString(fmt.Sprintf("\t%v: \n\t\t%s\n", theme.style("stacktrace").Sprintf("%q", key), theme.style("stacktrace").Sprintf("> %s", st)))
+		return
+	}
+
+	b.WriteString(fmt.Sprintf("\t%v: \n", theme.style("stacktrace").Sprintf("%q", key)))
+	shown, hidden := 0, 0
+	writeHidden := func() {
+		if hidden > 0 {
+			b.WriteString(fmt.Sprintf("\t\t%s\n", theme.style("muted").Sprintf("… %d more %s", hidden, plural(hidden, "frame", "frames"))))
+			hidden = 0
+		}
+	}
+	for _, it := range items {
+		if !it.isFrame {
+			if strings.TrimSpace(it.text) != "" {
+				writeHidden()
+				b.WriteString(fmt.Sprintf("\t\t%s\n", theme.style("stacktrace").Sprintf("%s", it.text)))
+			}
+			continue
+		}
+
+		f := it.frame
+		kind := f.kind(p.Module)
+		if p.MaxFrames > 0 && (kind != appFrame || shown == p.MaxFrames) {
+			hidden++
+			continue
+		}
+		if kind == appFrame {
+			shown++
+		}
+
+		loc := fmt.Sprintf("%s:%d", trimPath(f.File, funcPackage(f.Func), p.Module), f.Line)
+		if kind == appFrame {
+			b.WriteString(fmt.Sprintf("\t\t> %s %s\n", theme.style("frame").Sprintf("%s", f.Func), theme.style("frame.location").Sprintf("%s", loc)))
+		} else {
+			b.WriteString(fmt.Sprintf("\t\t%s\n", theme.style("frame.other").Sprintf("> %s %s", f.Func, loc)))
+		}
+	}
+	writeHidden()
+}
+
+// hasFrame reports whether any of the lines of a stacktrace is a frame.
+func hasFrame(items []stackItem) bool {
+	for _, it := range items {
+		if it.isFrame {
+			return true
+		}
+	}
+	return false
+}
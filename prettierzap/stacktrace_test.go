@@ -0,0 +1,181 @@
+package prettierzap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+const testStack = `github.com/acme/app/server.(*Handler).ServeHTTP
+	/home/dev/app/server/handler.go:42
+net/http.serverHandler.ServeHTTP
+	/usr/local/go/src/net/http/server.go:2936
+github.com/gorilla/mux.(*Router).ServeHTTP
+	/home/dev/go/pkg/mod/github.com/gorilla/mux@v1.8.0/mux.go:210
+github.com/acme/app/server.Run
+	/home/dev/app/server/run.go:12
+runtime.goexit
+	/usr/local/go/src/runtime/asm_amd64.s:1650`
+
+func TestParseStack(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		Text   string
+		Count  int
+		First  Frame
+		Parsed bool
+	}{
+		{"pass - zap stacktrace", testStack, 5, Frame{Func: "github.com/acme/app/server.(*Handler).ServeHTTP", File: "/home/dev/app/server/handler.go", Line: 42}, true},
+		{"pass - debug.Stack", "goroutine 7 [running]:\nmain.work(0xc000010000)\n\t/src/app/main.go:8 +0x1d\ncreated by main.main in goroutine 1\n\t/src/app/main.go:11 +0x5a\n", 2, Frame{Func: "main.work", File: "/src/app/main.go", Line: 8}, true},
+		{"fails - empty text", "", 0, Frame{}, false},
+		{"fails - text shorter than two bytes", "x", 0, Frame{}, false},
+		{"fails - text", "boom", 0, Frame{}, false},
+		{"fails - location without line", "main.main\n\tmain.go", 0, Frame{}, false},
+		{"fails - location not indented", "main.main\nmain.go:1", 0, Frame{}, false},
+		{"fails - text after the frames", testStack + "\nexit status 2", 0, Frame{}, false},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			frames, ok := ParseStack(tc.Text)
+			if ok != tc.Parsed || len(frames) != tc.Count {
+				t.Fatalf("ParseStack: expected %d frames parsed %v received: %v %+v", tc.Count, tc.Parsed, ok, frames)
+			}
+			if tc.Parsed && frames[0] != tc.First {
+				t.Errorf("checkFrame: expected %+v received: %+v", tc.First, frames[0])
+			}
+		})
+	}
+
+	frames, _ := ParseStack("goroutine 7 [running]:\nmain.work()\n\t/src/app/main.go:8\ncreated by main.main in goroutine 1\n\t/src/app/main.go:11 +0x5a\n")
+	if len(frames) != 2 || frames[1].Func != "created by main.main" {
+		t.Errorf("checkCreatedBy: expected the function starting the goroutine received: %+v", frames)
+	}
+}
+
+func TestFrameKind(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		Frame  Frame
+		Module string
+		Wanted frameKind
+	}{
+		{"pass - frame of the module", Frame{Func: "github.com/acme/app/server.Run", File: "/home/dev/app/server/run.go"}, "github.com/acme/app", appFrame},
+		{"pass - frame out of GOPATH without module", Frame{Func: "github.com/acme/app/server.Run", File: "/home/dev/app/server/run.go"}, "", appFrame},
+		{"pass - module with the same prefix", Frame{Func: "github.com/acme/application.Run", File: "/home/dev/application/run.go"}, "github.com/acme/app", dependencyFrame},
+		{"pass - module cache", Frame{Func: "github.com/gorilla/mux.(*Router).ServeHTTP", File: "/go/pkg/mod/github.com/gorilla/mux@v1.8.0/mux.go"}, "", dependencyFrame},
+		{"pass - vendor directory", Frame{Func: "github.com/gorilla/mux.(*Router).ServeHTTP", File: "/app/vendor/github.com/gorilla/mux/mux.go"}, "", dependencyFrame},
+		{"pass - standard library", Frame{Func: "net/http.serverHandler.ServeHTTP", File: "/usr/local/go/src/net/http/server.go"}, "", stdlibFrame},
+		{"pass - runtime", Frame{Func: "runtime.goexit", File: "/usr/local/go/src/runtime/asm_amd64.s"}, "", runtimeFrame},
+		{"pass - runtime package", Frame{Func: "runtime/debug.Stack", File: "/usr/local/go/src/runtime/debug/stack.go"}, "", runtimeFrame},
+		{"pass - created by", Frame{Func: "created by main.main", File: "/src/app/main.go"}, "github.com/acme/app", appFrame},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			if got := tc.Frame.kind(tc.Module); got != tc.Wanted {
+				t.Errorf("kind(%s, %q): expected %v received: %v", tc.Frame.Func, tc.Module, tc.Wanted, got)
+			}
+		})
+	}
+}
+
+func TestTrimPath(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		File   string
+		Pkg    string
+		Module string
+		Wanted string
+	}{
+		{"pass - module cache", "/home/dev/go/pkg/mod/github.com/gorilla/mux@v1.8.0/mux.go", "github.com/gorilla/mux", "", "github.com/gorilla/mux@v1.8.0/mux.go"},
+		{"pass - vendor directory", "/app/vendor/github.com/gorilla/mux/mux.go", "github.com/gorilla/mux", "", "github.com/gorilla/mux/mux.go"},
+		{"pass - standard library", "/usr/local/go/src/net/http/server.go", "net/http", "", "net/http/server.go"},
+		{"pass - module", "/home/dev/app/server/run.go", "github.com/acme/app/server", "github.com/acme/app", "github.com/acme/app/server/run.go"},
+		{"pass - unknown module", "/home/dev/app/server/run.go", "github.com/acme/app/server", "", "/home/dev/app/server/run.go"},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			if got := trimPath(tc.File, tc.Pkg, tc.Module); got != tc.Wanted {
+				t.Errorf("trimPath(%s): expected %s received: %s", tc.File, tc.Wanted, got)
+			}
+		})
+	}
+}
+
+func TestWriteStacktrace(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	testScenarios := []struct {
+		Name      string
+		MaxFrames int
+		Text      string
+		Wanted    []string
+		Frames    int
+	}{
+		{"pass - frames", 0, testStack, []string{
+			"> github.com/acme/app/server.(*Handler).ServeHTTP github.com/acme/app/server/handler.go:42\n",
+			"> net/http.serverHandler.ServeHTTP net/http/server.go:2936\n",
+			"> runtime.goexit runtime/asm_amd64.s:1650\n",
+		}, 5},
+		{"pass - top frame of the application", 1, testStack, []string{"… 4 more frames"}, 1},
+		{"pass - text after the frames", 0, testStack + "\nexit status 2\n", []string{
+			"> github.com/gorilla/mux.(*Router).ServeHTTP github.com/gorilla/mux@v1.8.0/mux.go:210\n",
+			"> runtime.goexit runtime/asm_amd64.s:1650\n\t\texit status 2\n",
+		}, 5},
+		{"pass - text written as it is", 0, "not a stack\n\tat all", []string{"> not a stack"}, 2},
+		{"pass - stacktrace shorter than two bytes", 0, "x", []string{"\t\"stacktrace\": \n\t\t> x\n"}, 1},
+		{"pass - empty stacktrace", 0, "", []string{"\t\"stacktrace\": \n\t\t> \n"}, 1},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := NewPrinter()
+			p.Module = "github.com/acme/app"
+			p.MaxFrames = tc.MaxFrames
+			var b bytes.Buffer
+			p.writeStacktrace(&b, "stacktrace", tc.Text)
+			for _, want := range tc.Wanted {
+				if !strings.Contains(b.String(), want) {
+					t.Errorf("writeStacktrace: expected %q in the output received: %q", want, b.String())
+				}
+			}
+			if n := strings.Count(b.String(), "> "); n != tc.Frames {
+				t.Errorf("checkFrames: expected %d frames received: %d in %q", tc.Frames, n, b.String())
+			}
+		})
+	}
+}
+
+func TestShortStacktrace(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	testScenarios := []struct {
+		Name string
+		Line string
+	}{
+		{"pass - empty stacktrace", `{"level":"error","msg":"a","stacktrace":""}`},
+		{"pass - stacktrace shorter than two bytes", `{"level":"error","msg":"a","stacktrace":"x"}`},
+		{"pass - stacktrace of one byte that isn't a string", `{"level":"error","msg":"a","stacktrace":1}`},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			pj, _ := ParseJSONByteArray([]byte(tc.Line))
+			s, err := GenerateOutputString(pj, false)
+			if err != nil {
+				t.Fatalf("GenerateOutputString: expected no error received: %v", err)
+			}
+			if !strings.Contains(s, `"stacktrace": `) {
+				t.Errorf("GenerateOutputString: expected the stacktrace field received: %q", s)
+			}
+		})
+	}
+}
@@ -0,0 +1,323 @@
+package prettierzap
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+	"unicode/utf8"
+)
+
+// presets of the output template
+const (
+	TemplateCompact = "compact"  // one line per record, with the meta fields as key=value pairs
+	TemplateZapDev  = "zap-dev"  // the lines of zap's console encoder of development loggers
+	TemplateVerbose = "verbose"  // every core field, full timestamps and the meta fields below the line
+	TemplateMsgOnly = "msg-only" // just the messages
+)
+
+// templatePresets maps the names of the template presets to their templates.
+var templatePresets = map[string]string{
+	TemplateCompact: `{{with .Time}}{{color "timestamp" .}} {{end}}` +
+		`{{with .Level}}{{levelColor "level" . (pad 5 (upper .))}} {{end}}` +
+		`{{with .Logger}}{{loggerColor . (printf "<%s>" .)}} {{end}}` +
+		`{{with .Caller}}{{color "caller" .}} {{end}}` +
+		`{{levelColor "message" .Level .Msg}}{{with .MetaInline}} {{.}}{{end}}` + "\n" +
+		`{{.Stacktrace}}`,
+	TemplateZapDev: `{{with .Time "2006-01-02T15:04:05.000Z0700"}}{{.}}` + "\t" + `{{end}}` +
+		`{{with .Level}}{{levelColor "level" . (upper .)}}` + "\t" + `{{end}}` +
+		`{{with .Logger}}{{.}}` + "\t" + `{{end}}` +
+		`{{with .Caller}}{{.}}` + "\t" + `{{end}}` +
+		`{{.Msg}}{{with .FieldsJSON}}` + "\t" + `{{.}}{{end}}` + "\n" +
+		`{{with .StacktraceText}}{{.}}` + "\n" + `{{end}}`,
+	TemplateVerbose: `{{with .Time "2006-01-02T15:04:05.000000000Z07:00"}}{{color "timestamp" .}} {{end}}` +
+		`{{with .Level}}{{levelColor "level" . (pad 6 (upper .))}} {{end}}` +
+		`{{with .Source}}{{color "source" .}} {{end}}` +
+		`{{with .Logger}}{{loggerColor . (printf "<%s>" .)}} {{end}}` +
+		`{{with .Caller}}{{color "caller" (printf "@[%s]" .)}} {{end}}` +
+		`{{levelColor "message" .Level .Msg}}` + "\n" +
+		`{{.Stacktrace}}{{.Meta}}`,
+	TemplateMsgOnly: `{{.Msg}}`,
+}
+
+// TemplateNames returns the names of the template presets.
+func TemplateNames() []string {
+	names := make([]string, 0, len(templatePresets))
+	for name := range templatePresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ParseTemplate returns the output template with the given name: one of the presets, `default` for the
+// built-in layout, or a Go text/template.
+func ParseTemplate(name string) string {
+	switch n := strings.ToLower(strings.TrimSpace(name)); n {
+	case "", "default":
+		return ""
+	default:
+		if t, ok := templatePresets[n]; ok {
+			return t
+		}
+	}
+	return name
+}
+
+// SetTemplate sets the Go text/template the records are written with in the pretty output,
+// or the built-in layout if it is empty.
+// templates read the fields of records with the methods of templateRecord, and can use the functions
+// of templateFuncs, like `{{.Time}} {{.Level | upper | pad 5 | levelColor "level" .Level}} {{.Msg}}`
+// or `{{field "http.status"}} {{duration (field "latency")}}`.
+// a new line is added after the records that don't end with one.
+func (p *Printer) SetTemplate(text string) error {
+	if text == "" {
+		p.template = nil
+		return nil
+	}
+	t, err := template.New("record").Funcs(p.templateFuncs()).Parse(text)
+	if err != nil {
+		return err
+	}
+	p.template = newRecordTemplate(t)
+	return nil
+}
+
+// recordTemplate represents a parsed template with the copies it is executed with, one for each
+// record written at the same time, since the field function of a copy reads the record it executes.
+type recordTemplate struct {
+	runs sync.Pool
+}
+
+// templateRun represents a copy of a template, bound to the record it executes.
+type templateRun struct {
+	t *template.Template
+	r templateRecord
+}
+
+// newRecordTemplate creates the template executing copies of t.
+func newRecordTemplate(t *template.Template) *recordTemplate {
+	rt := &recordTemplate{}
+	rt.runs.New = func() interface{} {
+		// t is never executed, so it can always be cloned
+		clone, _ := t.Clone()
+		run := &templateRun{}
+		run.t = clone.Funcs(template.FuncMap{"field": func(path string) string { return run.r.Field(path) }})
+		return run
+	}
+	return rt
+}
+
+// execute writes the record with a copy of the template.
+func (rt *recordTemplate) execute(b *bytes.Buffer, r templateRecord) error {
+	run := rt.runs.Get().(*templateRun)
+	defer rt.runs.Put(run)
+	run.r = r
+	return run.t.Execute(b, r)
+}
+
+// renderTemplate writes the record with the template of the printer.
+func (p *Printer) renderTemplate(pj ParsedJSON) (string, error) {
+	var b bytes.Buffer
+	if err := p.template.execute(&b, templateRecord{p, pj}); err != nil {
+		return "", err
+	}
+	if b.Len() == 0 || b.Bytes()[b.Len()-1] != '\n' {
+		b.WriteByte('\n')
+	}
+	return b.String(), nil
+}
+
+// templateFuncs returns the functions of the templates of the printer:
+//
+//	field path                  returns the text of the field with the path, like the Field method of the record
+//	color role text             styles the text with the style of the role in the theme, like `caller`
+//	levelColor role level text  styles the text with the style of the role of the level, like `level.error`
+//	loggerColor name text       styles the text with the color of the logger name
+//	pad n text                  pads the text with spaces to n characters, on its left if n is negative
+//	truncate n text             cuts the text to n characters, ending it with `…`
+//	duration value              formats a duration given in seconds, like zap writes them, or like `1.5s`
+//	upper text, lower text      change the case of the text
+func (p *Printer) templateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"color": func(role, text string) (string, error) {
+			if !containsKey(themeRoles, role) {
+				return "", fmt.Errorf("unknown role %q", role)
+			}
+			return p.theme().style(role).Sprintf("%s", text), nil
+		},
+		"levelColor": func(role, level, text string) (string, error) {
+			if !containsKey(themeRoles, role) {
+				return "", fmt.Errorf("unknown role %q", role)
+			}
+			sev, known := ParseSeverity(level)
+			return p.theme().levelStyle(role, sev, known).Sprintf("%s", text), nil
+		},
+		"loggerColor": func(name, text string) string {
+			return loggerColor(name)("%s", text)
+		},
+		"field": func(path string) string {
+			// replaced in the copies of the template by the field of the record they execute
+			return ""
+		},
+		"pad":      padText,
+		"truncate": truncateText,
+		"duration": formatDuration,
+		"upper":    strings.ToUpper,
+		"lower":    strings.ToLower,
+	}
+}
+
+// padText pads the text with spaces to n characters, on its left if n is negative.
+func padText(n int, text string) string {
+	left := n < 0
+	if left {
+		n = -n
+	}
+	missing := n - utf8.RuneCountInString(text)
+	if missing <= 0 {
+		return text
+	}
+	if left {
+		return strings.Repeat(" ", missing) + text
+	}
+	return text + strings.Repeat(" ", missing)
+}
+
+// truncateText cuts the text to n characters, ending it with `…` if it is cut.
+func truncateText(n int, text string) string {
+	if n <= 0 || utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	runes := []rune(text)
+	return string(runes[:n-1]) + "…"
+}
+
+// formatDuration formats a duration given as a number of seconds, like zap's default duration encoder
+// writes them, or as a Go duration. other values are returned as they are.
+func formatDuration(value string) string {
+	if s, err := strconv.ParseFloat(value, 64); err == nil {
+		return roundDuration(time.Duration(s * float64(time.Second))).String()
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return roundDuration(d).String()
+	}
+	return value
+}
+
+// templateRecord represents a record in the templates.
+type templateRecord struct {
+	p  *Printer
+	pj ParsedJSON
+}
+
+// Time returns the timestamp of the record, formatted with the time format of the printer or the given layout.
+// timestamps that can't be read are returned as they are.
+func (r templateRecord) Time(layout ...string) string {
+	t, err := r.pj.GetTime()
+	if err != nil {
+		return valueOf(r.pj.GetTimestamp()).String()
+	}
+	if len(layout) > 0 {
+		return t.In(r.p.location()).Format(layout[0])
+	}
+	return r.p.formatTime(t)
+}
+
+// Level returns the level of the record.
+func (r templateRecord) Level() string { return levelOf(r.pj) }
+
+// Logger returns the logger name of the record.
+func (r templateRecord) Logger() string { return nameOf(r.pj) }
+
+// Caller returns the caller of the record.
+func (r templateRecord) Caller() string { return callerOf(r.pj) }
+
+// Msg returns the message of the record.
+func (r templateRecord) Msg() string { return msgOf(r.pj) }
+
+// Source returns the file the record was read from, if the input has several files.
+func (r templateRecord) Source() string { return SourceOf(r.pj) }
+
+// Field returns the text of the field with the given path, like `http.status` or `tags[0]`,
+// or nothing if the record doesn't have it.
+func (r templateRecord) Field(path string) string {
+	v, _ := (queryRecord{r.pj}).value(path)
+	return v.String()
+}
+
+// fields returns the meta fields of the record without its stacktrace, and their keys in the order of the printer.
+func (r templateRecord) fields() (map[string]string, []string) {
+	meta := r.p.meta(r.pj)
+	dropStack(r.pj, meta)
+	return meta, r.p.metaKeys(r.pj, meta)
+}
+
+// Fields returns the text of the meta fields of the record, without its stacktrace.
+func (r templateRecord) Fields() map[string]string {
+	meta, _ := r.fields()
+	fields := make(map[string]string, len(meta))
+	for k, raw := range meta {
+		fields[k] = valueOf(raw).String()
+	}
+	return fields
+}
+
+// MetaInline returns the meta fields of the record as logfmt pairs, without its stacktrace.
+func (r templateRecord) MetaInline() string {
+	meta, keys := r.fields()
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + logfmtQuote(valueOf(meta[k]).String())
+	}
+	return strings.Join(pairs, " ")
+}
+
+// FieldsJSON returns the meta fields of the record as a JSON object, without its stacktrace,
+// or nothing if it has none.
+func (r templateRecord) FieldsJSON() string {
+	meta, keys := r.fields()
+	if len(keys) == 0 {
+		return ""
+	}
+	var b bytes.Buffer
+	b.WriteByte('{')
+	for i, k := range keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(jsonQuote(k))
+		b.WriteByte(':')
+		b.WriteString(strings.TrimSpace(meta[k]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// Meta returns the meta fields of the record written below its line, like the built-in layout does,
+// without its stacktrace.
+func (r templateRecord) Meta() string {
+	meta := r.p.meta(r.pj)
+	dropStack(r.pj, meta)
+	var b bytes.Buffer
+	r.p.writeMeta(&b, r.pj, meta)
+	return b.String()
+}
+
+// Stacktrace returns the stacktrace of the record, or the goroutines of its dump,
+// written below its line like the built-in layout does.
+func (r templateRecord) Stacktrace() string {
+	var b bytes.Buffer
+	r.p.writeStack(&b, r.pj, r.p.meta(r.pj))
+	return b.String()
+}
+
+// StacktraceText returns the text of the stacktrace of the record.
+func (r templateRecord) StacktraceText() string {
+	meta := r.p.meta(r.pj)
+	return valueOf(meta[mappingOf(r.pj).stacktraceKey(meta)]).String()
+}
@@ -0,0 +1,86 @@
+package prettierzap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	line := `{"level":"error","ts":1522426145.187,"logger":"api","caller":"a/b.go:1","msg":"boom","status":500,"user":{"id":1},"stacktrace":"main.main\n\t/src/app/main.go:3"}`
+	pj, _ := ParseJSONByteArray([]byte(line))
+
+	testScenarios := []struct {
+		Name     string
+		Template string
+		Wanted   string
+	}{
+		{"pass - compact preset", ParseTemplate(TemplateCompact), "30/03/2018 16:09:05 ERROR <api> a/b.go:1 boom status=500 user=\"{\\\"id\\\":1}\"\n\t\"stacktrace\": \n\t\t> main.main app/main.go:3\n"},
+		{"pass - zap-dev preset", ParseTemplate(TemplateZapDev), "2018-03-30T16:09:05.187Z\tERROR\tapi\ta/b.go:1\tboom\t{\"status\":500,\"user\":{\"id\":1}}\nmain.main\n\t/src/app/main.go:3\n"},
+		{"pass - msg-only preset", ParseTemplate(TemplateMsgOnly), "boom\n"},
+		{"pass - functions", `{{.Level | upper | pad -6}}|{{truncate 3 .Msg}}|{{.Field "user.id"}}|{{.Field "missing"}}|{{duration "0.0123"}}`, " ERROR|bo…|1||12.3ms\n"},
+		{"pass - field function", `{{field "user.id"}}|{{field "missing"}}|{{field "status" | pad 4}}|{{duration (field "status")}}`, "1||500 |8m20s\n"},
+		{"pass - fields", `{{range $k, $v := .Fields}}{{$k}}={{$v}};{{end}}`, "status=500;user={\"id\":1};\n"},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := NewPrinter()
+			p.Location = time.UTC
+			if err := p.SetTemplate(tc.Template); err != nil {
+				t.Fatalf("SetTemplate: expected no error received: %v", err)
+			}
+			got, err := p.render(pj)
+			if err != nil {
+				t.Fatalf("render: expected no error received: %v", err)
+			}
+			if got != tc.Wanted {
+				t.Errorf("render: expected %q received: %q", tc.Wanted, got)
+			}
+		})
+	}
+}
+
+func TestSetTemplate(t *testing.T) {
+	testScenarios := []struct {
+		Name        string
+		Template    string
+		SetFails    bool
+		RenderFails bool
+	}{
+		{"pass - compact preset", ParseTemplate(TemplateCompact), false, false},
+		{"pass - zap-dev preset", ParseTemplate(TemplateZapDev), false, false},
+		{"pass - verbose preset", ParseTemplate(TemplateVerbose), false, false},
+		{"pass - msg-only preset", ParseTemplate(TemplateMsgOnly), false, false},
+		{"fails - invalid template", "{{.Msg", true, false},
+		{"fails - unknown function", `{{nope .Msg}}`, true, false},
+		{"fails - unknown role", `{{color "nope" .Msg}}`, false, true},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := NewPrinter()
+			if err := p.SetTemplate(tc.Template); (err != nil) != tc.SetFails {
+				t.Fatalf("SetTemplate: expected error %v received: %v", tc.SetFails, err)
+			}
+			if tc.SetFails {
+				return
+			}
+			if _, err := p.render(parsedLog{"msg": `"a"`}); (err != nil) != tc.RenderFails {
+				t.Errorf("render: expected error %v received: %v", tc.RenderFails, err)
+			}
+		})
+	}
+
+	if len(TemplateNames()) != 4 {
+		t.Errorf("TemplateNames: expected the 4 presets received: %v", TemplateNames())
+	}
+	if ParseTemplate("default") != "" || ParseTemplate("{{.Msg}}") != "{{.Msg}}" {
+		t.Errorf("ParseTemplate: expected the built-in layout for default and templates as they are")
+	}
+}
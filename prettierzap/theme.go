@@ -0,0 +1,443 @@
+package prettierzap
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Style represents the attributes a piece of text is written with, like its foreground and background colors.
+// the zero style writes text as it is.
+type Style struct {
+	c *color.Color
+}
+
+// Sprintf formats the text and wraps it with the attributes of the style, unless colors are turned off.
+func (s Style) Sprintf(format string, a ...interface{}) string {
+	if s.c == nil {
+		return fmt.Sprintf(format, a...)
+	}
+	return s.c.Sprintf(format, a...)
+}
+
+// ColorDepth represents the number of colors a terminal can show.
+type ColorDepth int
+
+// color depths of terminals
+const (
+	Color16   ColorDepth = iota // the 16 ANSI colors
+	Color256                    // the 256 colors of xterm
+	TrueColor                   // 24-bit RGB colors
+)
+
+// DetectColorDepth returns the color depth of the terminal from the COLORTERM and TERM environment variables.
+func DetectColorDepth() ColorDepth {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return TrueColor
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return Color256
+	}
+	return Color16
+}
+
+// names of the color modes
+const (
+	ColorAuto   = "auto"   // colors on terminals, unless the NO_COLOR environment variable is set
+	ColorAlways = "always" // colors even if the output isn't a terminal
+	ColorNever  = "never"  // no colors
+)
+
+// SetColorMode turns the colors of the output on or off with the given mode.
+func SetColorMode(mode string) error {
+	switch strings.ToLower(strings.TrimSpace(mode)) {
+	case "", ColorAuto:
+		// colors are on for terminals by default, see https://no-color.org for NO_COLOR
+		if os.Getenv("NO_COLOR") != "" {
+			color.NoColor = true
+		}
+	case ColorAlways:
+		color.NoColor = false
+	case ColorNever:
+		color.NoColor = true
+	default:
+		return fmt.Errorf("unknown color mode %q", mode)
+	}
+	return nil
+}
+
+// roles of the text of the pretty output styled by themes.
+// a role like `level.error` falls back to the style of `level` if a theme doesn't set it.
+var themeRoles = []string{
+	"timestamp",
+	"level", "level.debug", "level.info", "level.warn", "level.error", "level.panic", "level.fatal",
+	"message", "message.debug", "message.info", "message.warn", "message.error", "message.panic", "message.fatal",
+	"source", "caller", "key", "value", "muted",
+	"stacktrace", "frame", "frame.location", "frame.other", "goroutine",
+}
+
+// names of the built-in themes
+const (
+	ThemeDark         = "dark"
+	ThemeLight        = "light"
+	ThemeHighContrast = "high-contrast"
+	ThemeColorblind   = "colorblind"
+)
+
+// builtinThemes are the styles of the roles of the built-in themes.
+var builtinThemes = map[string]map[string]string{
+	ThemeDark: {
+		"timestamp":      "black on yellow",
+		"level":          "bold black on yellow",
+		"message.debug":  "yellow",
+		"message.warn":   "yellow",
+		"message.error":  "red",
+		"message.panic":  "red",
+		"message.fatal":  "red",
+		"source":         "hi-black",
+		"caller":         "cyan",
+		"key":            "cyan",
+		"muted":          "hi-black",
+		"stacktrace":     "red",
+		"frame":          "bold red",
+		"frame.location": "cyan",
+		"frame.other":    "hi-black",
+		"goroutine":      "yellow",
+	},
+	ThemeLight: {
+		"timestamp":      "blue",
+		"level":          "bold",
+		"level.debug":    "bold magenta",
+		"level.info":     "bold blue",
+		"level.warn":     "bold #af5f00",
+		"level.error":    "bold red",
+		"level.panic":    "bold white on red",
+		"level.fatal":    "bold white on red",
+		"message.debug":  "#5f5f5f",
+		"message.warn":   "#af5f00",
+		"message.error":  "red",
+		"message.panic":  "red",
+		"message.fatal":  "red",
+		"source":         "#5f5f5f",
+		"caller":         "blue",
+		"key":            "blue",
+		"muted":          "#5f5f5f",
+		"stacktrace":     "red",
+		"frame":          "bold red",
+		"frame.location": "blue",
+		"frame.other":    "#5f5f5f",
+		"goroutine":      "#af5f00",
+	},
+	ThemeHighContrast: {
+		"timestamp":      "bold hi-white on black",
+		"level":          "bold black on hi-white",
+		"level.info":     "bold black on hi-cyan",
+		"level.warn":     "bold black on hi-yellow",
+		"level.error":    "bold hi-white on red",
+		"level.panic":    "bold hi-white on magenta",
+		"level.fatal":    "bold hi-white on magenta",
+		"message.debug":  "hi-white",
+		"message.warn":   "bold hi-yellow",
+		"message.error":  "bold hi-red",
+		"message.panic":  "bold hi-red",
+		"message.fatal":  "bold hi-red",
+		"source":         "white",
+		"caller":         "hi-cyan",
+		"key":            "bold hi-cyan",
+		"muted":          "white",
+		"stacktrace":     "bold hi-red",
+		"frame":          "bold hi-red",
+		"frame.location": "hi-cyan",
+		"frame.other":    "white",
+		"goroutine":      "bold hi-yellow",
+	},
+	// colors of the Okabe-Ito palette, told apart with any color vision deficiency
+	ThemeColorblind: {
+		"timestamp":      "#999999",
+		"level":          "bold",
+		"level.debug":    "bold #56b4e9",
+		"level.info":     "bold #009e73",
+		"level.warn":     "bold #e69f00",
+		"level.error":    "bold #d55e00",
+		"level.panic":    "bold reverse #d55e00",
+		"level.fatal":    "bold reverse #d55e00",
+		"message.debug":  "#56b4e9",
+		"message.warn":   "#e69f00",
+		"message.error":  "#d55e00",
+		"message.panic":  "#d55e00",
+		"message.fatal":  "#d55e00",
+		"source":         "#999999",
+		"caller":         "#56b4e9",
+		"key":            "#56b4e9",
+		"muted":          "#999999",
+		"stacktrace":     "#d55e00",
+		"frame":          "bold #d55e00",
+		"frame.location": "#56b4e9",
+		"frame.other":    "#999999",
+		"goroutine":      "#e69f00",
+	},
+}
+
+// ThemeNames returns the names of the built-in themes.
+func ThemeNames() []string {
+	names := make([]string, 0, len(builtinThemes))
+	for name := range builtinThemes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ThemeStyles returns the styles of the roles of a built-in theme, or false if there is no theme with the name.
+func ThemeStyles(name string) (map[string]string, bool) {
+	t, ok := builtinThemes[strings.ToLower(strings.TrimSpace(name))]
+	if !ok {
+		return nil, false
+	}
+	styles := make(map[string]string, len(t))
+	for role, spec := range t {
+		styles[role] = spec
+	}
+	return styles, true
+}
+
+// Theme represents the styles of the roles of the pretty output.
+type Theme struct {
+	styles map[string]Style
+}
+
+// defaultTheme is the theme of printers without one.
+var defaultTheme, _ = NewTheme(builtinThemes[ThemeDark], Color16)
+
+// NewTheme returns the theme with the given styles of roles, like `timestamp: bold blue` or `level.error: white on red`.
+// colors the terminal can't show are replaced with the closest ones it can.
+func NewTheme(styles map[string]string, depth ColorDepth) (*Theme, error) {
+	t := &Theme{styles: make(map[string]Style, len(themeRoles))}
+	for role, spec := range styles {
+		if !containsKey(themeRoles, role) {
+			return nil, fmt.Errorf("unknown role %q, the roles are %s", role, strings.Join(themeRoles, ", "))
+		}
+		s, err := ParseStyle(spec, depth)
+		if err != nil {
+			return nil, fmt.Errorf("invalid style of %s: %v", role, err)
+		}
+		t.styles[role] = s
+	}
+
+	// roles are listed after the ones they fall back to
+	for _, role := range themeRoles {
+		if _, ok := styles[role]; ok {
+			continue
+		}
+		if i := strings.IndexByte(role, '.'); i >= 0 {
+			t.styles[role] = t.styles[role[:i]]
+		}
+	}
+	return t, nil
+}
+
+// style returns the style of the role.
+func (t *Theme) style(role string) Style {
+	return t.styles[role]
+}
+
+// severityRole returns the suffix of the roles of a severity, like `error` of `level.error`.
+func severityRole(sev Severity) string {
+	switch {
+	case sev <= DebugSeverity:
+		return debugLevel
+	case sev == InfoSeverity:
+		return infoLevel
+	case sev == WarnSeverity:
+		return warningLevel
+	case sev == ErrorSeverity:
+		return errorLevel
+	case sev < FatalSeverity:
+		return panicLevel
+	}
+	return fatalLevel
+}
+
+// levelStyle returns the style of a role of a level, like `level` or `message`, or of the role itself for unknown levels.
+func (t *Theme) levelStyle(role string, sev Severity, known bool) Style {
+	if !known {
+		return t.style(role)
+	}
+	return t.style(role + "." + severityRole(sev))
+}
+
+// theme returns the theme of the printer.
+func (p *Printer) theme() *Theme {
+	if p.Theme == nil {
+		return defaultTheme
+	}
+	return p.Theme
+}
+
+// names of the 8 ANSI colors, in the order of their codes
+var colorNames = []string{"black", "red", "green", "yellow", "blue", "magenta", "cyan", "white"}
+
+// text attributes of styles
+var styleAttributes = map[string]color.Attribute{
+	"bold":      color.Bold,
+	"dim":       color.Faint,
+	"faint":     color.Faint,
+	"italic":    color.Italic,
+	"underline": color.Underline,
+	"blink":     color.BlinkSlow,
+	"reverse":   color.ReverseVideo,
+}
+
+// ParseStyle parses a style like `bold white on red`: text attributes, a foreground color and,
+// after `on`, a background color. colors are ANSI names like `cyan` or `hi-black`, 256-color indexes
+// like `208` and RGB colors like `#ff8700`. `default` or an empty style writes text as it is.
+func ParseStyle(spec string, depth ColorDepth) (Style, error) {
+	var attrs []color.Attribute
+	background := false
+	for _, word := range strings.Fields(strings.ToLower(spec)) {
+		if word == "on" {
+			background = true
+			continue
+		}
+		if a, ok := styleAttributes[word]; ok {
+			attrs = append(attrs, a)
+			continue
+		}
+		if word == "default" || word == "none" {
+			continue
+		}
+
+		c, err := parseColor(word, depth, background)
+		if err != nil {
+			return Style{}, err
+		}
+		attrs = append(attrs, c...)
+		background = false
+	}
+	if background {
+		return Style{}, fmt.Errorf("missing background color in %q", spec)
+	}
+	if len(attrs) == 0 {
+		return Style{}, nil
+	}
+	return Style{color.New(attrs...)}, nil
+}
+
+// parseColor returns the SGR parameters of a foreground or background color, with the given depth.
+func parseColor(word string, depth ColorDepth, background bool) ([]color.Attribute, error) {
+	var index int
+	switch {
+	case strings.HasPrefix(word, "#"):
+		rgb, err := strconv.ParseUint(word[1:], 16, 32)
+		if err != nil || len(word) != 7 {
+			return nil, fmt.Errorf("invalid RGB color %q, expected #rrggbb", word)
+		}
+		r, g, b := int(rgb>>16), int(rgb>>8&0xff), int(rgb&0xff)
+		if depth == TrueColor {
+			return sgrColor(background, 2, r, g, b), nil
+		}
+		index = closestColor(r, g, b, depth)
+	case word[0] >= '0' && word[0] <= '9':
+		n, err := strconv.Atoi(word)
+		if err != nil || n > 255 {
+			return nil, fmt.Errorf("invalid color index %q, expected 0 to 255", word)
+		}
+		index = n
+		if depth == Color16 && n >= 16 {
+			r, g, b := paletteRGB(n)
+			index = closestColor(r, g, b, Color16)
+		}
+	default:
+		name := strings.TrimPrefix(word, "hi-")
+		index = indexOf(colorNames, name)
+		if index < 0 {
+			return nil, fmt.Errorf("unknown color %q", word)
+		}
+		if name != word {
+			index += 8
+		}
+	}
+
+	if index >= 16 {
+		return sgrColor(background, 5, index), nil
+	}
+	code := color.Attribute(30 + index%8)
+	if index >= 8 {
+		code += 60
+	}
+	if background {
+		code += 10
+	}
+	return []color.Attribute{code}, nil
+}
+
+// sgrColor returns the SGR parameters of an extended color, of the 256 colors or an RGB one.
+func sgrColor(background bool, kind int, values ...int) []color.Attribute {
+	attrs := []color.Attribute{38, color.Attribute(kind)}
+	if background {
+		attrs[0] = 48
+	}
+	for _, v := range values {
+		attrs = append(attrs, color.Attribute(v))
+	}
+	return attrs
+}
+
+// ansiRGB are the RGB values of the 16 ANSI colors, as xterm shows them.
+var ansiRGB = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// paletteRGB returns the RGB values of a color of the 256 colors of xterm.
+func paletteRGB(index int) (int, int, int) {
+	switch {
+	case index < 16:
+		c := ansiRGB[index]
+		return c[0], c[1], c[2]
+	case index < 232:
+		level := func(n int) int {
+			if n == 0 {
+				return 0
+			}
+			return 55 + n*40
+		}
+		n := index - 16
+		return level(n / 36), level(n / 6 % 6), level(n % 6)
+	}
+	gray := 8 + (index-232)*10
+	return gray, gray, gray
+}
+
+// closestColor returns the index of the color the closest to an RGB color, of the 16 ANSI colors
+// or, with 256 colors, of the colors of xterm's color cube and gray ramp.
+func closestColor(r, g, b int, depth ColorDepth) int {
+	from, to := 0, 16
+	if depth == Color256 {
+		from, to = 16, 256
+	}
+	best, bestDistance := from, -1
+	for i := from; i < to; i++ {
+		pr, pg, pb := paletteRGB(i)
+		d := (pr-r)*(pr-r) + (pg-g)*(pg-g) + (pb-b)*(pb-b)
+		if bestDistance < 0 || d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best
+}
+
+// indexOf returns the index of the string in the list, or -1 if it isn't in it.
+func indexOf(list []string, s string) int {
+	for i, item := range list {
+		if item == s {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,149 @@
+package prettierzap
+
+import (
+	"os"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestParseStyle(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	testScenarios := []struct {
+		Name   string
+		Spec   string
+		Depth  ColorDepth
+		Wanted string
+		Fails  bool
+	}{
+		{"pass - empty style", "", Color16, "x", false},
+		{"pass - default style", "default", Color16, "x", false},
+		{"pass - color name", "red", Color16, "\x1b[31mx\x1b[0m", false},
+		{"pass - attribute and bright color", "bold hi-black", Color16, "\x1b[1;90mx\x1b[0m", false},
+		{"pass - background", "black on yellow", Color16, "\x1b[30;43mx\x1b[0m", false},
+		{"pass - bright background", "white on hi-red", Color16, "\x1b[37;101mx\x1b[0m", false},
+		{"pass - palette color", "208", Color256, "\x1b[38;5;208mx\x1b[0m", false},
+		{"pass - palette color in 16 colors", "208", Color16, "\x1b[33mx\x1b[0m", false},
+		{"pass - rgb color", "#ff8700", TrueColor, "\x1b[38;2;255;135;0mx\x1b[0m", false},
+		{"pass - rgb color in 256 colors", "#ff8700", Color256, "\x1b[38;5;208mx\x1b[0m", false},
+		{"pass - rgb background", "on #808080", Color256, "\x1b[48;5;244mx\x1b[0m", false},
+		{"pass - any case", "UNDERLINE Cyan", Color16, "\x1b[4;36mx\x1b[0m", false},
+		{"fails - unknown color name", "purple", TrueColor, "", true},
+		{"fails - bad #rrggbb", "#fff", TrueColor, "", true},
+		{"fails - palette color out of range", "256", TrueColor, "", true},
+		{"fails - missing background", "red on", TrueColor, "", true},
+		{"fails - missing bright color", "hi-", TrueColor, "", true},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			s, err := ParseStyle(tc.Spec, tc.Depth)
+			if (err != nil) != tc.Fails {
+				t.Fatalf("ParseStyle(%q): expected error %v received: %v", tc.Spec, tc.Fails, err)
+			}
+			if tc.Fails {
+				return
+			}
+			if got := s.Sprintf("%s", "x"); got != tc.Wanted {
+				t.Errorf("ParseStyle(%q): expected %q received: %q", tc.Spec, tc.Wanted, got)
+			}
+		})
+	}
+}
+
+func TestNewTheme(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		Base   string
+		Styles map[string]string
+		Fails  bool
+	}{
+		{"pass - dark theme", ThemeDark, nil, false},
+		{"pass - light theme", ThemeLight, nil, false},
+		{"pass - high-contrast theme", ThemeHighContrast, nil, false},
+		{"pass - colorblind theme", ThemeColorblind, nil, false},
+		{"pass - styles over a base theme", ThemeLight, map[string]string{"level.error": "white on red"}, false},
+		{"fails - unknown base theme", "sepia", nil, true},
+		{"fails - unknown role", ThemeDark, map[string]string{"levels": "red"}, true},
+		{"fails - invalid style", ThemeDark, map[string]string{"caller": "purple"}, true},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			styles, ok := ThemeStyles(tc.Base)
+			if !ok {
+				if !tc.Fails {
+					t.Fatalf("ThemeStyles(%s): expected the built-in theme", tc.Base)
+				}
+				return
+			}
+			for role, spec := range tc.Styles {
+				styles[role] = spec
+			}
+			for _, depth := range []ColorDepth{Color16, Color256, TrueColor} {
+				if _, err := NewTheme(styles, depth); (err != nil) != tc.Fails {
+					t.Errorf("NewTheme(%s): expected error %v received: %v", tc.Base, tc.Fails, err)
+				}
+			}
+		})
+	}
+
+	if len(ThemeNames()) != 4 {
+		t.Errorf("ThemeNames: expected the 4 built-in themes received: %v", ThemeNames())
+	}
+
+	noColor := color.NoColor
+	color.NoColor = false
+	defer func() { color.NoColor = noColor }()
+
+	theme, err := NewTheme(map[string]string{"level": "bold", "level.error": "red"}, Color16)
+	if err != nil {
+		t.Fatalf("NewTheme: expected no error received: %v", err)
+	}
+	if got := theme.levelStyle("level", InfoSeverity, true).Sprintf("x"); got != "\x1b[1mx\x1b[0m" {
+		t.Errorf("checkFallback: expected the style of level received: %q", got)
+	}
+	if got := theme.levelStyle("level", ErrorSeverity, true).Sprintf("x"); got != "\x1b[31mx\x1b[0m" {
+		t.Errorf("checkFallback: expected the style of level.error received: %q", got)
+	}
+	if got := theme.style("caller").Sprintf("x"); got != "x" {
+		t.Errorf("checkFallback: expected unstyled callers received: %q", got)
+	}
+}
+
+func TestSetColorMode(t *testing.T) {
+	noColor := color.NoColor
+	defer func() { color.NoColor = noColor }()
+
+	os.Setenv("NO_COLOR", "1")
+	defer os.Unsetenv("NO_COLOR")
+
+	testScenarios := []struct {
+		Name    string
+		Mode    string
+		NoColor bool
+		Wanted  bool
+		Fails   bool
+	}{
+		{"pass - auto follows NO_COLOR", ColorAuto, false, true, false},
+		{"pass - always", ColorAlways, true, false, false},
+		{"pass - never", ColorNever, false, true, false},
+		{"fails - bad --color mode", "sometimes", false, false, true},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			color.NoColor = tc.NoColor
+			err := SetColorMode(tc.Mode)
+			if (err != nil) != tc.Fails {
+				t.Fatalf("SetColorMode(%s): expected error %v received: %v", tc.Mode, tc.Fails, err)
+			}
+			if !tc.Fails && color.NoColor != tc.Wanted {
+				t.Errorf("SetColorMode(%s): expected NoColor %v received: %v", tc.Mode, tc.Wanted, color.NoColor)
+			}
+		})
+	}
+}
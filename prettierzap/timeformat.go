@@ -0,0 +1,126 @@
+package prettierzap
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimeFormat is the layout used for the timestamps of the output.
+const DefaultTimeFormat = "02/01/2006 15:04:05"
+
+// presets of the output time format
+const (
+	TimeFormatRelative = "relative" // how long ago the log was written, like `3m12s ago`
+	TimeFormatDelta    = "delta"    // time elapsed since the previous printed log, like `+1.25s`
+)
+
+// timeFormatPresets maps the names of the time format presets to their layouts.
+var timeFormatPresets = map[string]string{
+	"default":     DefaultTimeFormat,
+	"rfc3339":     time.RFC3339,
+	"rfc3339nano": time.RFC3339Nano,
+	"iso8601":     "2006-01-02T15:04:05.000Z0700",
+	"kitchen":     time.Kitchen,
+	"stamp":       time.Stamp,
+	"stampmilli":  time.StampMilli,
+	"stampmicro":  time.StampMicro,
+	"datetime":    "2006-01-02 15:04:05",
+	"millis":      "2006-01-02 15:04:05.000",
+}
+
+// ParseTimeFormat returns the output time format with the given name: one of the presets,
+// `relative`, `delta` (or `delta-since-previous`), or a Go time layout.
+func ParseTimeFormat(name string) string {
+	switch n := strings.ToLower(strings.TrimSpace(name)); n {
+	case "":
+		return DefaultTimeFormat
+	case TimeFormatRelative:
+		return TimeFormatRelative
+	case TimeFormatDelta, "delta-since-previous":
+		return TimeFormatDelta
+	default:
+		if layout, ok := timeFormatPresets[n]; ok {
+			return layout
+		}
+	}
+	return name
+}
+
+// ParseLocation returns the time zone with the given name: `UTC`, `Local` or an IANA name like `Asia/Tehran`.
+func ParseLocation(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "", "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// formatTime formats the time of a log with the time format and the time zone of the printer.
+func (p *Printer) formatTime(t time.Time) string {
+	layout := p.TimeFormat
+	if layout == "" {
+		layout = DefaultTimeFormat
+	}
+
+	switch layout {
+	case TimeFormatRelative:
+		now := time.Now
+		if p.now != nil {
+			now = p.now
+		}
+		return formatAgo(now().Sub(t))
+	case TimeFormatDelta:
+		var d time.Duration
+		if !p.prevTime.IsZero() {
+			d = t.Sub(p.prevTime)
+		}
+		p.prevTime = t
+		if d < 0 {
+			return "-" + roundDuration(-d).String()
+		}
+		return "+" + roundDuration(d).String()
+	}
+	return t.In(p.location()).Format(layout)
+}
+
+// location returns the time zone of the printer, local time if it isn't set.
+func (p *Printer) location() *time.Location {
+	if p.Location == nil {
+		return time.Local
+	}
+	return p.Location
+}
+
+// formatAgo formats how long ago something happened, with at most two units.
+func formatAgo(d time.Duration) string {
+	suffix := " ago"
+	if d < 0 {
+		d, suffix = -d, " ahead"
+	}
+
+	switch {
+	case d < time.Second:
+		return fmt.Sprintf("%dms%s", d/time.Millisecond, suffix)
+	case d < time.Minute:
+		return fmt.Sprintf("%ds%s", d/time.Second, suffix)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm%ds%s", d/time.Minute, d%time.Minute/time.Second, suffix)
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm%s", d/time.Hour, d%time.Hour/time.Minute, suffix)
+	}
+	return fmt.Sprintf("%dd%dh%s", d/(24*time.Hour), d%(24*time.Hour)/time.Hour, suffix)
+}
+
+// roundDuration rounds a duration to a precision fitting its size.
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Minute:
+		return d.Round(time.Second)
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Microsecond)
+}
@@ -0,0 +1,64 @@
+package prettierzap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTime(t *testing.T) {
+	tehran := time.FixedZone("IRDT", 4*3600+1800)
+	at := time.Date(2018, 3, 30, 16, 9, 5, 187278300, time.UTC)
+
+	testScenarios := []struct {
+		Name     string
+		Format   string
+		Location *time.Location
+		Wanted   string
+	}{
+		{"pass - default layout", "", time.UTC, "30/03/2018 16:09:05"},
+		{"pass - preset in utc", "rfc3339nano", time.UTC, "2018-03-30T16:09:05.1872783Z"},
+		{"pass - preset in another zone", "rfc3339", tehran, "2018-03-30T20:39:05+04:30"},
+		{"pass - millis", "millis", time.UTC, "2018-03-30 16:09:05.187"},
+		{"pass - kitchen", "kitchen", time.UTC, "4:09PM"},
+		{"pass - go layout", "15:04:05.000000", time.UTC, "16:09:05.187278"},
+		{"pass - relative", "relative", time.UTC, "3m12s ago"},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			p := Printer{
+				TimeFormat: ParseTimeFormat(tc.Format),
+				Location:   tc.Location,
+				now:        func() time.Time { return at.Add(3*time.Minute + 12*time.Second) },
+			}
+			if got := p.formatTime(at); got != tc.Wanted {
+				t.Errorf("formatTime: expected %q received: %q", tc.Wanted, got)
+			}
+		})
+	}
+}
+
+func TestFormatTimeDelta(t *testing.T) {
+	p := Printer{TimeFormat: ParseTimeFormat("delta-since-previous")}
+	at := time.Unix(1522426145, 0)
+
+	wanted := []string{"+0s", "+1.25s", "+250µs", "-2s"}
+	times := []time.Time{at, at.Add(1250 * time.Millisecond), at.Add(1250*time.Millisecond + 250*time.Microsecond), at.Add(-750*time.Millisecond + 250*time.Microsecond)}
+	for i, tt := range times {
+		if got := p.formatTime(tt); got != wanted[i] {
+			t.Errorf("formatTime: expected @(%d) %q received: %q", i, wanted[i], got)
+		}
+	}
+}
+
+func TestParseLocation(t *testing.T) {
+	if loc, err := ParseLocation("utc"); err != nil || loc != time.UTC {
+		t.Errorf("ParseLocation: expected UTC received: %v(%v)", loc, err)
+	}
+	if loc, err := ParseLocation("Local"); err != nil || loc != time.Local {
+		t.Errorf("ParseLocation: expected Local received: %v(%v)", loc, err)
+	}
+	if _, err := ParseLocation("Mars/Olympus_Mons"); err == nil {
+		t.Errorf("ParseLocation: expected an error for an unknown zone")
+	}
+}
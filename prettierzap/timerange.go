@@ -0,0 +1,158 @@
+package prettierzap
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// layouts accepted for absolute times given by the user, besides the layouts of logs
+var specLayouts = []string{
+	"2006-01-02T15:04",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// layouts accepted for times of the day given by the user
+var clockLayouts = []string{
+	"15:04:05.999999999",
+	"15:04",
+	"3:04pm",
+	"3pm",
+}
+
+// ParseTimeSpec converts a time given by the user into a time, relative to now.
+// the accepted forms are:
+//
+//	now, today, yesterday           the current time, or the start of today or yesterday
+//	14:30, 14:30:05, 2:30pm         a time of today
+//	today 9:00, yesterday 14:30     a time of today or yesterday
+//	15m, 2h ago, 1h30m, 3d          a duration before now
+//	2018-03-30, 2018-03-30 14:30    a date or a date and time
+//	2018-03-30T14:30:05+03:30       an RFC3339/ISO8601 time
+//	1522426145.187                  an epoch timestamp, in seconds, millis or nanos
+//
+// times without a zone are in the given location.
+func ParseTimeSpec(spec string, now time.Time, loc *time.Location) (time.Time, error) {
+	s := strings.ToLower(strings.TrimSpace(spec))
+	if s == "" {
+		return time.Time{}, fmt.Errorf("empty time")
+	}
+	now = now.In(loc)
+
+	switch s {
+	case "now":
+		return now, nil
+	case "today":
+		return startOfDay(now), nil
+	case "yesterday":
+		return startOfDay(now).AddDate(0, 0, -1), nil
+	}
+
+	for _, day := range []string{"today ", "yesterday "} {
+		if strings.HasPrefix(s, day) {
+			base := startOfDay(now)
+			if day == "yesterday " {
+				base = base.AddDate(0, 0, -1)
+			}
+			if t, ok := parseClock(strings.TrimSpace(s[len(day):]), base); ok {
+				return t, nil
+			}
+			return time.Time{}, fmt.Errorf("invalid time of day in %q", spec)
+		}
+	}
+
+	if t, ok := parseClock(s, startOfDay(now)); ok {
+		return t, nil
+	}
+
+	if d, ok := parseRelative(s); ok {
+		return now.Add(-d), nil
+	}
+
+	if isNumeric(s) {
+		return ParseTime(Value{kind: NumberKind, raw: s}, TimeEncodingAuto)
+	}
+
+	trimmed := strings.TrimSpace(spec)
+	for _, layouts := range [][]string{timeLayouts, specLayouts} {
+		for _, layout := range layouts {
+			if t, err := time.ParseInLocation(layout, trimmed, loc); err == nil {
+				return t, nil
+			}
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported time %q", spec)
+}
+
+// startOfDay returns the midnight starting the day of the given time, in its location.
+func startOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// parseClock parses a time of the day and places it on the day of base.
+func parseClock(s string, base time.Time) (time.Time, bool) {
+	for _, layout := range clockLayouts {
+		c, err := time.Parse(layout, s)
+		if err != nil {
+			continue
+		}
+		y, m, d := base.Date()
+		return time.Date(y, m, d, c.Hour(), c.Minute(), c.Second(), c.Nanosecond(), base.Location()), true
+	}
+	return time.Time{}, false
+}
+
+// parseRelative parses a duration before now like `15m`, `2h ago`, `-90s` or `3d`.
+func parseRelative(s string) (time.Duration, bool) {
+	s = strings.TrimSpace(strings.TrimSuffix(s, "ago"))
+	s = strings.TrimPrefix(s, "-")
+	s = strings.Replace(s, " ", "", -1)
+	if s == "" {
+		return 0, false
+	}
+
+	// time.ParseDuration has no unit for days
+	var days time.Duration
+	if i := strings.IndexByte(s, 'd'); i > 0 {
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return 0, false
+		}
+		days = time.Duration(n) * 24 * time.Hour
+		s = s[i+1:]
+		if s == "" {
+			return days, true
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, false
+	}
+	return days + d, true
+}
+
+// filterTimeRange reports whether the time of the log is in the range of the filter.
+// the range includes its start and excludes its end, logs without a readable time are out of any range.
+func filterTimeRange(pj ParsedJSON, f LogFilter) bool {
+	if f.Since.IsZero() && f.Until.IsZero() {
+		return true
+	}
+
+	t, err := pj.GetTime()
+	return err == nil && inTimeRange(t, f)
+}
+
+// inTimeRange reports whether the time is in the range of the filter.
+func inTimeRange(t time.Time, f LogFilter) bool {
+	if !f.Since.IsZero() && t.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !t.Before(f.Until) {
+		return false
+	}
+	return true
+}
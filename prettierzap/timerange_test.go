@@ -0,0 +1,79 @@
+package prettierzap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeSpec(t *testing.T) {
+	tehran := time.FixedZone("IRST", 3*3600+1800)
+	now := time.Date(2018, 3, 30, 16, 9, 5, 0, tehran)
+
+	testScenarios := []struct {
+		Name   string
+		Spec   string
+		Wanted time.Time
+		Fails  bool
+	}{
+		{"pass - now", "now", now, false},
+		{"pass - today", "today", time.Date(2018, 3, 30, 0, 0, 0, 0, tehran), false},
+		{"pass - yesterday", "Yesterday", time.Date(2018, 3, 29, 0, 0, 0, 0, tehran), false},
+		{"pass - time of day", "14:30", time.Date(2018, 3, 30, 14, 30, 0, 0, tehran), false},
+		{"pass - time of day with seconds", "14:30:05", time.Date(2018, 3, 30, 14, 30, 5, 0, tehran), false},
+		{"pass - time of day in 12-hour clock", "2:30pm", time.Date(2018, 3, 30, 14, 30, 0, 0, tehran), false},
+		{"pass - time of yesterday", "yesterday 09:15", time.Date(2018, 3, 29, 9, 15, 0, 0, tehran), false},
+		{"pass - duration", "15m", now.Add(-15 * time.Minute), false},
+		{"pass - duration ago", "2h ago", now.Add(-2 * time.Hour), false},
+		{"pass - days and hours", "1d2h", now.Add(-26 * time.Hour), false},
+		{"pass - date", "2018-03-01", time.Date(2018, 3, 1, 0, 0, 0, 0, tehran), false},
+		{"pass - date and time", "2018-03-01 10:20", time.Date(2018, 3, 1, 10, 20, 0, 0, tehran), false},
+		{"pass - rfc3339 keeps its zone", "2018-03-01T10:20:00Z", time.Date(2018, 3, 1, 10, 20, 0, 0, time.UTC), false},
+		{"pass - epoch", "1522426145", time.Unix(1522426145, 0), false},
+		{"fails - empty", " ", time.Time{}, true},
+		{"fails - text", "last tuesday", time.Time{}, true},
+		{"fails - bad time of day", "yesterday noon", time.Time{}, true},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := ParseTimeSpec(tc.Spec, now, tehran)
+			if tc.Fails != (err != nil) {
+				t.Fatalf("ParseTimeSpec(%q): expected error: %v received: %v", tc.Spec, tc.Fails, err)
+			}
+			if !tc.Fails && !got.Equal(tc.Wanted) {
+				t.Errorf("ParseTimeSpec(%q): expected %v received: %v", tc.Spec, tc.Wanted, got)
+			}
+		})
+	}
+}
+
+func TestFilterTimeRange(t *testing.T) {
+	logs := []ParsedJSON{
+		parsedLog{"ts": "1522426100.5"},
+		parsedLog{"ts": `"2018-03-30T16:09:05Z"`},
+		parsedLog{"ts": "1522426200000"},
+		parsedLog{"ts": `"not a time"`},
+		parsedLog{"msg": `"no time"`},
+	}
+
+	testScenarios := []struct {
+		Name   string
+		Filter LogFilter
+		Wanted []bool
+	}{
+		{"pass - no range", LogFilter{}, []bool{true, true, true, true, true}},
+		{"pass - since", LogFilter{Since: time.Unix(1522426145, 0)}, []bool{false, true, true, false, false}},
+		{"pass - until excludes its end", LogFilter{Until: time.Unix(1522426145, 0)}, []bool{true, false, false, false, false}},
+		{"pass - window", LogFilter{Since: time.Unix(1522426100, 0), Until: time.Unix(1522426150, 0)}, []bool{true, true, false, false, false}},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			for i, pj := range logs {
+				if got := filterTimeRange(pj, tc.Filter); got != tc.Wanted[i] {
+					t.Errorf("filterTimeRange: expected @(%d) %v received: %v", i, tc.Wanted[i], got)
+				}
+			}
+		})
+	}
+}
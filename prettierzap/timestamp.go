@@ -0,0 +1,226 @@
+package prettierzap
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TimeEncoding represents how the timestamps of logs are encoded.
+// it is either one of the predefined encodings or a Go time layout.
+type TimeEncoding string
+
+// predefined time encodings, matching zap's time encoders
+const (
+	TimeEncodingAuto        TimeEncoding = "auto"         // detect the encoding of each timestamp
+	TimeEncodingEpoch       TimeEncoding = "epoch"        // float seconds, zapcore.EpochTimeEncoder
+	TimeEncodingEpochMillis TimeEncoding = "epoch-millis" // float milliseconds, zapcore.EpochMillisTimeEncoder
+	TimeEncodingEpochMicros TimeEncoding = "epoch-micros" // integer microseconds
+	TimeEncodingEpochNanos  TimeEncoding = "epoch-nanos"  // integer nanoseconds, zapcore.EpochNanosTimeEncoder
+	TimeEncodingISO8601     TimeEncoding = "iso8601"      // zapcore.ISO8601TimeEncoder
+	TimeEncodingRFC3339     TimeEncoding = "rfc3339"      // zapcore.RFC3339TimeEncoder and RFC3339NanoTimeEncoder
+)
+
+// layouts tried, in order, for timestamps encoded as strings
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+	"2006/01/02 15:04:05.999999999",
+	time.RFC1123Z,
+	time.RFC1123,
+}
+
+// epoch values at or above these bounds are taken as finer units when the encoding is detected.
+// 1e11 seconds is in the year 5138 while 1e11 milliseconds is in 1973, and so on for finer units.
+const (
+	epochMillisBound = 1e11
+	epochMicrosBound = 1e14
+	epochNanosBound  = 1e17
+)
+
+var (
+	timeMu       sync.RWMutex
+	timeEncoding = TimeEncodingAuto
+)
+
+// SetTimeEncoding sets the encoding used to read the timestamps of logs.
+func SetTimeEncoding(enc TimeEncoding) {
+	timeMu.Lock()
+	defer timeMu.Unlock()
+	timeEncoding = enc
+}
+
+// currentTimeEncoding returns the encoding used to read the timestamps of logs.
+func currentTimeEncoding() TimeEncoding {
+	timeMu.RLock()
+	defer timeMu.RUnlock()
+	return timeEncoding
+}
+
+// ParseTimeEncoding returns the time encoding with the given name.
+// names that aren't predefined encodings are taken as Go time layouts.
+func ParseTimeEncoding(name string) TimeEncoding {
+	switch enc := TimeEncoding(strings.ToLower(name)); enc {
+	case "", TimeEncodingAuto:
+		return TimeEncodingAuto
+	case TimeEncodingEpoch, TimeEncodingEpochMillis, TimeEncodingEpochMicros, TimeEncodingEpochNanos, TimeEncodingISO8601, TimeEncodingRFC3339:
+		return enc
+	case "epoch-seconds", "seconds":
+		return TimeEncodingEpoch
+	case "millis":
+		return TimeEncodingEpochMillis
+	case "micros":
+		return TimeEncodingEpochMicros
+	case "nanos":
+		return TimeEncodingEpochNanos
+	}
+	return TimeEncoding(name)
+}
+
+// ParseTime converts a timestamp value into a time with the given encoding.
+// sub-second precision of epoch timestamps is kept by reading their digits instead of a float.
+func ParseTime(v Value, enc TimeEncoding) (time.Time, error) {
+	text := strings.TrimSpace(v.String())
+	if text == "" {
+		return time.Time{}, fmt.Errorf("empty timestamp")
+	}
+
+	switch enc {
+	case TimeEncodingEpoch:
+		return parseEpoch(text, time.Second)
+	case TimeEncodingEpochMillis:
+		return parseEpoch(text, time.Millisecond)
+	case TimeEncodingEpochMicros:
+		return parseEpoch(text, time.Microsecond)
+	case TimeEncodingEpochNanos:
+		return parseEpoch(text, time.Nanosecond)
+	case TimeEncodingISO8601, TimeEncodingRFC3339:
+		return parseLayouts(text, timeLayouts)
+	case "", TimeEncodingAuto:
+		if isNumeric(text) {
+			return parseEpoch(text, detectEpochUnit(text))
+		}
+		return parseLayouts(text, timeLayouts)
+	}
+	return time.ParseInLocation(string(enc), text, time.Local)
+}
+
+// detectEpochUnit guesses the unit of an epoch timestamp from its magnitude.
+func detectEpochUnit(text string) time.Duration {
+	n, _ := strconv.ParseFloat(text, 64)
+	n = math.Abs(n)
+	switch {
+	case n >= epochNanosBound:
+		return time.Nanosecond
+	case n >= epochMicrosBound:
+		return time.Microsecond
+	case n >= epochMillisBound:
+		return time.Millisecond
+	}
+	return time.Second
+}
+
+// parseEpoch converts a decimal number of the given unit since the epoch into a time.
+func parseEpoch(text string, unit time.Duration) (time.Time, error) {
+	intPart, fracPart := text, ""
+	if i := strings.IndexByte(text, '.'); i >= 0 {
+		intPart, fracPart = text[:i], text[i+1:]
+	}
+
+	if strings.ContainsAny(text, "eE") {
+		// exponent notation: precision is limited to the float anyway
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return time.Unix(0, 0).Add(time.Duration(f * float64(unit))), nil
+	}
+
+	i, err := strconv.ParseInt(intPart, 10, 64)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var frac time.Duration
+	if fracPart != "" {
+		if !isDigits(fracPart) {
+			return time.Time{}, fmt.Errorf("invalid timestamp %q", text)
+		}
+		// keep up to nanosecond precision of the fraction
+		if len(fracPart) > 9 {
+			fracPart = fracPart[:9]
+		}
+		n, _ := strconv.ParseInt(fracPart+strings.Repeat("0", 9-len(fracPart)), 10, 64)
+		frac = time.Duration(n) * unit / time.Second
+		if strings.HasPrefix(intPart, "-") {
+			frac = -frac
+		}
+	}
+
+	sec := int64(unit/time.Second) * i
+	nsec := int64(0)
+	if unit < time.Second {
+		per := int64(time.Second / unit)
+		sec = i / per
+		nsec = (i % per) * int64(unit)
+	}
+	return time.Unix(sec, nsec).Add(frac), nil
+}
+
+// parseLayouts parses the text with the first of the layouts that fits it.
+// timestamps without a zone are taken as local time.
+func parseLayouts(text string, layouts []string) (time.Time, error) {
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, text, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unsupported timestamp %q", text)
+}
+
+// timeOf returns the time of a raw timestamp read with the configured encoding.
+func timeOf(raw string) (time.Time, error) {
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("missing timestamp")
+	}
+	return ParseTime(valueOf(raw), currentTimeEncoding())
+}
+
+// compareTimestamps compares the time of the log with a timestamp given by the user,
+// chronologically when both can be read as times and as values otherwise.
+func compareTimestamps(pj ParsedJSON, ts string) int {
+	t, err := pj.GetTime()
+	if err == nil {
+		if ft, errParse := ParseTime(valueOf(ts), TimeEncodingAuto); errParse == nil {
+			switch {
+			case t.Before(ft):
+				return -1
+			case t.After(ft):
+				return 1
+			}
+			return 0
+		}
+	}
+	return compareValues(valueOf(pj.GetTimestamp()), valueOf(ts))
+}
+
+func isNumeric(s string) bool {
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,104 @@
+package prettierzap
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTime(t *testing.T) {
+	utc := func(s string) time.Time {
+		tt, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			panic(err)
+		}
+		return tt
+	}
+
+	testScenarios := []struct {
+		Name     string
+		Raw      string
+		Encoding TimeEncoding
+		Wanted   time.Time
+		Fails    bool
+	}{
+		{"pass - epoch seconds keep sub-second precision", "1522426145.1872783", TimeEncodingAuto, utc("2018-03-30T16:09:05.1872783Z"), false},
+		{"pass - epoch millis detected", "1522426145187.2783", TimeEncodingAuto, utc("2018-03-30T16:09:05.1872783Z"), false},
+		{"pass - epoch nanos detected", "1522426145187278300", TimeEncodingAuto, utc("2018-03-30T16:09:05.1872783Z"), false},
+		{"pass - epoch millis given", "1000.5", TimeEncodingEpochMillis, utc("1970-01-01T00:00:01.0005Z"), false},
+		{"pass - iso8601", `"2018-03-30T19:39:05.187+0330"`, TimeEncodingAuto, utc("2018-03-30T16:09:05.187Z"), false},
+		{"pass - rfc3339 nano", `"2018-03-30T16:09:05.187278300Z"`, TimeEncodingRFC3339, utc("2018-03-30T16:09:05.1872783Z"), false},
+		{"pass - custom layout", `"30/03/2018 16:09:05 +0000"`, ParseTimeEncoding("02/01/2006 15:04:05 -0700"), utc("2018-03-30T16:09:05Z"), false},
+		{"pass - exponent notation", "1.5e9", TimeEncodingEpoch, utc("2017-07-14T02:40:00Z"), false},
+		{"fails - text", `"yesterday"`, TimeEncodingAuto, time.Time{}, true},
+		{"fails - string for epoch", `"abc"`, TimeEncodingEpoch, time.Time{}, true},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			got, err := ParseTime(valueOf(tc.Raw), tc.Encoding)
+			if tc.Fails != (err != nil) {
+				t.Fatalf("ParseTime: expected error: %v received: %v", tc.Fails, err)
+			}
+			if !tc.Fails && !got.Equal(tc.Wanted) {
+				t.Errorf("ParseTime: expected %v received: %v", tc.Wanted.UTC(), got.UTC())
+			}
+		})
+	}
+}
+
+func TestParseTimeEncoding(t *testing.T) {
+	for name, wanted := range map[string]TimeEncoding{
+		"":             TimeEncodingAuto,
+		"AUTO":         TimeEncodingAuto,
+		"epoch-millis": TimeEncodingEpochMillis,
+		"nanos":        TimeEncodingEpochNanos,
+		"ISO8601":      TimeEncodingISO8601,
+		"2006-01-02":   TimeEncoding("2006-01-02"),
+	} {
+		if got := ParseTimeEncoding(name); got != wanted {
+			t.Errorf("ParseTimeEncoding(%q): expected %q received: %q", name, wanted, got)
+		}
+	}
+}
+
+func TestTimeKeys(t *testing.T) {
+	km := DefaultKeyMapping().Override(KeyMapping{Time: []string{"time", "ts"}})
+	pj, _ := parseLine(keyedParser(parseJSON), []byte(`{"level":"info","time":"2018-03-30T16:09:05Z","msg":"hi","user":"test"}`), &km)
+	if pj.GetTimestamp() != `"2018-03-30T16:09:05Z"` {
+		t.Errorf("GetTimestamp: expected the time key received: %v", pj.GetTimestamp())
+	}
+	if _, ok := pj.GetMeta()["time"]; ok {
+		t.Errorf("GetMeta: expected the time key not to be a meta field")
+	}
+
+	f := LogFilter{Timestamp: "2018-03-30T16:00:00Z"}
+	if !filterJSON(pj, f) {
+		t.Errorf("filterJSON: expected the log to be after the timestamp")
+	}
+	f = LogFilter{Timestamp: "1522426146"}
+	if filterJSON(pj, f) {
+		t.Errorf("filterJSON: expected the log to be before the epoch timestamp")
+	}
+}
+
+func TestTextRecordTime(t *testing.T) {
+	enc := currentTimeEncoding()
+	SetTimeEncoding(TimeEncodingRFC3339)
+	defer SetTimeEncoding(enc)
+
+	before := time.Now().Add(-time.Second)
+	text, _ := parseText([]byte("plain text"), defaultKeys)
+	dump, ok := newPanicLog("panic: boom", []string{"", "goroutine 1 [running]:", "main.main()", "\t/src/app/main.go:3 +0x1d"}, defaultKeys)
+	if !ok {
+		t.Fatalf("newPanicLog: expected the dump to be parsed")
+	}
+
+	for _, pj := range []ParsedJSON{text, dump} {
+		if tm, err := pj.GetTime(); err != nil || tm.Before(before) {
+			t.Errorf("GetTime: expected the time the record was read at received: %v (%v)", tm, err)
+		}
+		if !filterJSON(pj, LogFilter{Since: before}) {
+			t.Errorf("filterJSON: expected the record read now to pass --since")
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package prettierzap
+
+import (
+	"errors"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// tokenKind represents the kind of a JSON token.
+type tokenKind int
+
+const (
+	tokenInvalid tokenKind = iota
+	tokenEOF
+	tokenBeginObject
+	tokenEndObject
+	tokenBeginArray
+	tokenEndArray
+	tokenColon
+	tokenComma
+	tokenString
+	tokenNumber
+	tokenTrue
+	tokenFalse
+	tokenNull
+)
+
+// maxNestingDepth limits how deep objects and arrays may be nested in a single line.
+const maxNestingDepth = 512
+
+var (
+	errUnexpectedEOF   = errors.New("unexpected end of JSON input")
+	errUnexpectedToken = errors.New("unexpected token in JSON input")
+	errTooDeep         = errors.New("JSON input is nested too deeply")
+	errTrailingData    = errors.New("unexpected data after top-level JSON value")
+)
+
+// token represents a JSON token as a span of the tokenized data.
+// tokens never copy the underlying bytes.
+type token struct {
+	kind  tokenKind
+	start int
+	end   int
+}
+
+// tokenizer splits a JSON byte array into tokens.
+type tokenizer struct {
+	data []byte
+	pos  int
+}
+
+// skipSpace moves the position over JSON whitespace.
+func (t *tokenizer) skipSpace() {
+	for t.pos < len(t.data) {
+		switch t.data[t.pos] {
+		case ' ', '\t', '\n', '\r':
+			t.pos++
+		default:
+			return
+		}
+	}
+}
+
+// next returns the next token of the data.
+func (t *tokenizer) next() token {
+	t.skipSpace()
+	if t.pos >= len(t.data) {
+		return token{kind: tokenEOF, start: t.pos, end: t.pos}
+	}
+
+	start := t.pos
+	switch c := t.data[t.pos]; c {
+	case '{':
+		t.pos++
+		return token{tokenBeginObject, start, t.pos}
+	case '}':
+		t.pos++
+		return token{tokenEndObject, start, t.pos}
+	case '[':
+		t.pos++
+		return token{tokenBeginArray, start, t.pos}
+	case ']':
+		t.pos++
+		return token{tokenEndArray, start, t.pos}
+	case ':':
+		t.pos++
+		return token{tokenColon, start, t.pos}
+	case ',':
+		t.pos++
+		return token{tokenComma, start, t.pos}
+	case '"':
+		return t.scanString()
+	case 't':
+		return t.scanLiteral("true", tokenTrue)
+	case 'f':
+		return t.scanLiteral("false", tokenFalse)
+	case 'n':
+		return t.scanLiteral("null", tokenNull)
+	default:
+		if c == '-' || (c >= '0' && c <= '9') {
+			return t.scanNumber()
+		}
+	}
+	return token{kind: tokenInvalid, start: start, end: start + 1}
+}
+
+// scanLiteral scans one of the `true`, `false` or `null` literals.
+func (t *tokenizer) scanLiteral(lit string, kind tokenKind) token {
+	start := t.pos
+	end := start + len(lit)
+	if end > len(t.data) || string(t.data[start:end]) != lit {
+		return token{kind: tokenInvalid, start: start, end: start + 1}
+	}
+	t.pos = end
+	return token{kind, start, end}
+}
+
+// scanString scans a quoted string, including its quotes.
+// escape sequences are validated but not decoded.
+func (t *tokenizer) scanString() token {
+	start := t.pos
+	i := start + 1
+	for i < len(t.data) {
+		switch c := t.data[i]; {
+		case c == '"':
+			t.pos = i + 1
+			return token{tokenString, start, t.pos}
+		case c == '\\':
+			if i+1 >= len(t.data) {
+				return token{kind: tokenInvalid, start: start, end: len(t.data)}
+			}
+			switch t.data[i+1] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+				i += 2
+			case 'u':
+				if i+6 > len(t.data) || !isHex4(t.data[i+2:i+6]) {
+					return token{kind: tokenInvalid, start: start, end: i}
+				}
+				i += 6
+			default:
+				return token{kind: tokenInvalid, start: start, end: i}
+			}
+		case c < 0x20:
+			return token{kind: tokenInvalid, start: start, end: i}
+		default:
+			i++
+		}
+	}
+	return token{kind: tokenInvalid, start: start, end: len(t.data)}
+}
+
+// scanNumber scans a number following the JSON number grammar.
+func (t *tokenizer) scanNumber() token {
+	start := t.pos
+	i := start
+	d := t.data
+
+	if d[i] == '-' {
+		i++
+	}
+	switch {
+	case i < len(d) && d[i] == '0':
+		i++
+	case i < len(d) && d[i] >= '1' && d[i] <= '9':
+		for i < len(d) && isDigit(d[i]) {
+			i++
+		}
+	default:
+		return token{kind: tokenInvalid, start: start, end: i}
+	}
+
+	if i < len(d) && d[i] == '.' {
+		i++
+		if i >= len(d) || !isDigit(d[i]) {
+			return token{kind: tokenInvalid, start: start, end: i}
+		}
+		for i < len(d) && isDigit(d[i]) {
+			i++
+		}
+	}
+
+	if i < len(d) && (d[i] == 'e' || d[i] == 'E') {
+		i++
+		if i < len(d) && (d[i] == '+' || d[i] == '-') {
+			i++
+		}
+		if i >= len(d) || !isDigit(d[i]) {
+			return token{kind: tokenInvalid, start: start, end: i}
+		}
+		for i < len(d) && isDigit(d[i]) {
+			i++
+		}
+	}
+
+	t.pos = i
+	return token{tokenNumber, start, i}
+}
+
+// skipValue consumes one complete JSON value starting with the given token
+// and returns the end offset of that value.
+func (t *tokenizer) skipValue(tok token, depth int) (int, error) {
+	switch tok.kind {
+	case tokenString, tokenNumber, tokenTrue, tokenFalse, tokenNull:
+		return tok.end, nil
+	case tokenBeginObject:
+		return t.skipContainer(tokenEndObject, depth+1)
+	case tokenBeginArray:
+		return t.skipContainer(tokenEndArray, depth+1)
+	case tokenEOF:
+		return 0, errUnexpectedEOF
+	}
+	return 0, errUnexpectedToken
+}
+
+// skipContainer consumes the rest of an object or an array whose opening token has been read.
+func (t *tokenizer) skipContainer(closing tokenKind, depth int) (int, error) {
+	if depth > maxNestingDepth {
+		return 0, errTooDeep
+	}
+
+	tok := t.next()
+	if tok.kind == closing {
+		return tok.end, nil
+	}
+
+	for {
+		if closing == tokenEndObject {
+			if tok.kind != tokenString {
+				return 0, tokenError(tok)
+			}
+			if tok = t.next(); tok.kind != tokenColon {
+				return 0, tokenError(tok)
+			}
+			tok = t.next()
+		}
+
+		if _, err := t.skipValue(tok, depth); err != nil {
+			return 0, err
+		}
+
+		switch tok = t.next(); tok.kind {
+		case closing:
+			return tok.end, nil
+		case tokenComma:
+			tok = t.next()
+		default:
+			return 0, tokenError(tok)
+		}
+	}
+}
+
+// tokenError returns the error describing why the given token was not expected.
+func tokenError(tok token) error {
+	if tok.kind == tokenEOF {
+		return errUnexpectedEOF
+	}
+	return errUnexpectedToken
+}
+
+// scanObject tokenizes the given data as a single JSON object and calls fn for each of its
+// top-level members with the decoded key and the raw bytes of the value.
+// nested objects, arrays and strings are validated and handed over untouched.
+func scanObject(data []byte, fn func(key string, value []byte)) error {
+	return scanMembers(data, func(key, value []byte) error {
+		k, ok := unquote(key)
+		if !ok {
+			return errUnexpectedToken
+		}
+		fn(k, value)
+		return nil
+	})
+}
+
+// scanMembers tokenizes the given data as a single JSON object like scanObject,
+// but hands over the keys still quoted, so they can be compared without being decoded.
+// scanning stops at the first error returned by fn.
+func scanMembers(data []byte, fn func(key, value []byte) error) error {
+	t := tokenizer{data: data}
+
+	tok := t.next()
+	if tok.kind != tokenBeginObject {
+		return tokenError(tok)
+	}
+
+	tok = t.next()
+	if tok.kind != tokenEndObject {
+		for {
+			if tok.kind != tokenString {
+				return tokenError(tok)
+			}
+			key := data[tok.start:tok.end]
+
+			if tok = t.next(); tok.kind != tokenColon {
+				return tokenError(tok)
+			}
+
+			tok = t.next()
+			end, err := t.skipValue(tok, 1)
+			if err != nil {
+				return err
+			}
+			if err := fn(key, data[tok.start:end]); err != nil {
+				return err
+			}
+
+			tok = t.next()
+			if tok.kind == tokenEndObject {
+				break
+			}
+			if tok.kind != tokenComma {
+				return tokenError(tok)
+			}
+			tok = t.next()
+		}
+	}
+
+	if tok = t.next(); tok.kind != tokenEOF {
+		return errTrailingData
+	}
+	return nil
+}
+
+// unquote decodes a quoted JSON string.
+// strings without escape sequences are converted without any intermediate buffer.
+func unquote(s []byte) (string, bool) {
+	if len(s) < 2 || s[0] != '"' || s[len(s)-1] != '"' {
+		return "", false
+	}
+	s = s[1 : len(s)-1]
+
+	i := 0
+	for i < len(s) && s[i] != '\\' {
+		i++
+	}
+	if i == len(s) {
+		return string(s), true
+	}
+
+	b := make([]byte, i, len(s)+utf8.UTFMax)
+	copy(b, s[:i])
+	for i < len(s) {
+		c := s[i]
+		if c != '\\' {
+			b = append(b, c)
+			i++
+			continue
+		}
+		if i+1 >= len(s) {
+			return "", false
+		}
+
+		switch s[i+1] {
+		case '"', '\\', '/':
+			b = append(b, s[i+1])
+		case 'b':
+			b = append(b, '\b')
+		case 'f':
+			b = append(b, '\f')
+		case 'n':
+			b = append(b, '\n')
+		case 'r':
+			b = append(b, '\r')
+		case 't':
+			b = append(b, '\t')
+		case 'u':
+			r, ok := decodeHex4(s[i+2:])
+			if !ok {
+				return "", false
+			}
+			i += 6
+			if utf16.IsSurrogate(r) {
+				r2, ok := rune(-1), false
+				if i+6 <= len(s) && s[i] == '\\' && s[i+1] == 'u' {
+					r2, ok = decodeHex4(s[i+2:])
+				}
+				if dec := utf16.DecodeRune(r, r2); ok && dec != utf8.RuneError {
+					r = dec
+					i += 6
+				} else {
+					r = utf8.RuneError
+				}
+			}
+			b = append(b, string(r)...)
+			continue
+		default:
+			return "", false
+		}
+		i += 2
+	}
+	return string(b), true
+}
+
+// decodeHex4 decodes the four hex digits of a `\u` escape sequence.
+func decodeHex4(s []byte) (rune, bool) {
+	if len(s) < 4 || !isHex4(s[:4]) {
+		return 0, false
+	}
+
+	var r rune
+	for _, c := range s[:4] {
+		r <<= 4
+		switch {
+		case c >= '0' && c <= '9':
+			r |= rune(c - '0')
+		case c >= 'a' && c <= 'f':
+			r |= rune(c - 'a' + 10)
+		default:
+			r |= rune(c - 'A' + 10)
+		}
+	}
+	return r, true
+}
+
+func isHex4(s []byte) bool {
+	for _, c := range s {
+		if !isDigit(c) && !(c >= 'a' && c <= 'f') && !(c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
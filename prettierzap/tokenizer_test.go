@@ -0,0 +1,136 @@
+package prettierzap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestTokenizer(t *testing.T) {
+	type checkFunc func([]tokenKind, []string) error
+	checks := func(fns ...checkFunc) []checkFunc { return fns }
+
+	checkKinds := func(wanted ...tokenKind) checkFunc {
+		return func(kinds []tokenKind, _ []string) error {
+			if len(wanted) != len(kinds) {
+				return fmt.Errorf("checkKinds: expected kinds: %v received: %v", wanted, kinds)
+			}
+			for i := range wanted {
+				if wanted[i] != kinds[i] {
+					return fmt.Errorf("checkKinds: expected kind @(%d): %v received: %v", i, wanted, kinds)
+				}
+			}
+			return nil
+		}
+	}
+
+	checkText := func(i int, wanted string) checkFunc {
+		return func(_ []tokenKind, texts []string) error {
+			if i >= len(texts) || texts[i] != wanted {
+				return fmt.Errorf("checkText: expected text @(%d): %q received: %q", i, wanted, texts)
+			}
+			return nil
+		}
+	}
+
+	testScenarios := []struct {
+		Name   string
+		Input  string
+		Checks []checkFunc
+	}{
+		{
+			"pass - punctuation and literals",
+			` { } [ ] : , true false null `,
+			checks(
+				checkKinds(tokenBeginObject, tokenEndObject, tokenBeginArray, tokenEndArray, tokenColon, tokenComma, tokenTrue, tokenFalse, tokenNull, tokenEOF),
+			),
+		},
+		{
+			"pass - strings with escapes",
+			`"a\"b" "c\\" "\u00e9\n"`,
+			checks(
+				checkKinds(tokenString, tokenString, tokenString, tokenEOF),
+				checkText(0, `"a\"b"`),
+				checkText(1, `"c\\"`),
+				checkText(2, `"\u00e9\n"`),
+			),
+		},
+		{
+			"pass - numbers",
+			`0 -1 1522426145.1872783 1e10 -2.5E-3`,
+			checks(
+				checkKinds(tokenNumber, tokenNumber, tokenNumber, tokenNumber, tokenNumber, tokenEOF),
+				checkText(2, "1522426145.1872783"),
+				checkText(4, "-2.5E-3"),
+			),
+		},
+		{
+			"fails - unterminated string",
+			`"abc`,
+			checks(checkKinds(tokenInvalid)),
+		},
+		{
+			"fails - invalid escape",
+			`"\x"`,
+			checks(checkKinds(tokenInvalid)),
+		},
+		{
+			"fails - malformed number",
+			`1.`,
+			checks(checkKinds(tokenInvalid)),
+		},
+		{
+			"fails - malformed literal",
+			`nul`,
+			checks(checkKinds(tokenInvalid)),
+		},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			tz := tokenizer{data: []byte(tc.Input)}
+
+			kinds := make([]tokenKind, 0)
+			texts := make([]string, 0)
+			for {
+				tok := tz.next()
+				kinds = append(kinds, tok.kind)
+				texts = append(texts, tc.Input[tok.start:tok.end])
+				if tok.kind == tokenEOF || tok.kind == tokenInvalid {
+					break
+				}
+			}
+
+			for _, check := range tc.Checks {
+				if errCheck := check(kinds, texts); errCheck != nil {
+					t.Error(errCheck)
+				}
+			}
+		})
+	}
+}
+
+func TestUnquote(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		Input  string
+		Wanted string
+		OK     bool
+	}{
+		{"pass - plain string", `"hello"`, "hello", true},
+		{"pass - simple escapes", `"a\"b\\c\/d\n\t"`, "a\"b\\c/d\n\t", true},
+		{"pass - unicode escape", `"caf\u00e9"`, "café", true},
+		{"pass - surrogate pair", `"\ud83d\ude00"`, "\U0001F600", true},
+		{"pass - lone surrogate", `"\ud83d!"`, "\uFFFD!", true},
+		{"fails - missing quotes", `hello`, "", false},
+		{"fails - bad escape", `"\q"`, "", false},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			s, ok := unquote([]byte(tc.Input))
+			if ok != tc.OK || s != tc.Wanted {
+				t.Errorf("unquote: expected (%q, %v) received: (%q, %v)", tc.Wanted, tc.OK, s, ok)
+			}
+		})
+	}
+}
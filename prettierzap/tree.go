@@ -0,0 +1,88 @@
+package prettierzap
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// default limits of the nested fields rendering
+const (
+	DefaultMaxDepth      = 4
+	DefaultMaxArrayItems = 10
+)
+
+// treeIndent is the indentation added for each level of nesting.
+const treeIndent = "   "
+
+// writeField writes a meta field, expanding objects and arrays into an indented tree below the key.
+func (p *Printer) writeField(b *bytes.Buffer, label string, v Value, indent string, depth int) {
+	b.WriteString(indent)
+	b.WriteString(label)
+	b.WriteString(":")
+
+	if !isContainer(v) {
+		b.WriteString(" ")
+		b.WriteString(p.theme().style("value").Sprintf("%s", quoteValue(v)))
+		b.WriteString("\n")
+		return
+	}
+
+	if p.MaxDepth > 0 && depth >= p.MaxDepth {
+		b.WriteString(" ")
+		b.WriteString(p.theme().style("muted").Sprintf("%s", collapsedValue(v)))
+		b.WriteString("\n")
+		return
+	}
+
+	b.WriteString("\n")
+	p.writeTree(b, v, indent+treeIndent, depth+1)
+}
+
+// writeTree writes the members or the elements of the given container value.
+func (p *Printer) writeTree(b *bytes.Buffer, v Value, indent string, depth int) {
+	if members, ok := v.AsObject(); ok {
+		for _, m := range members {
+			p.writeField(b, p.theme().style("key").Sprintf("%q", m.Key), m.Value, indent, depth)
+		}
+		return
+	}
+
+	elems, _ := v.AsArray()
+	for i, e := range elems {
+		if p.MaxArrayItems > 0 && i >= p.MaxArrayItems {
+			b.WriteString(indent)
+			b.WriteString(p.theme().style("muted").Sprintf("… %d more %s", len(elems)-i, plural(len(elems)-i, "item", "items")))
+			b.WriteString("\n")
+			return
+		}
+		p.writeField(b, p.theme().style("muted").Sprintf("[%d]", i), e, indent, depth)
+	}
+}
+
+// isContainer reports whether the value is a non-empty object or array.
+func isContainer(v Value) bool {
+	if members, ok := v.AsObject(); ok {
+		return len(members) > 0
+	}
+	if elems, ok := v.AsArray(); ok {
+		return len(elems) > 0
+	}
+	return false
+}
+
+// collapsedValue returns the compact JSON of a container deeper than the maximum depth, on one line.
+func collapsedValue(v Value) string {
+	var b bytes.Buffer
+	if err := json.Compact(&b, []byte(v.Raw())); err != nil {
+		return strings.Join(strings.Fields(v.Raw()), " ")
+	}
+	return b.String()
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
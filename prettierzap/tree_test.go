@@ -0,0 +1,108 @@
+package prettierzap
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/fatih/color"
+)
+
+func TestWriteTree(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	type checkFunc func(string) error
+	checks := func(fns ...checkFunc) []checkFunc { return fns }
+
+	checkLines := func(wanted ...string) checkFunc {
+		return func(out string) error {
+			lines := strings.Split(strings.TrimRight(out, "\n"), "\n")
+			if len(lines) != len(wanted) {
+				return fmt.Errorf("checkLines: expected %d lines: %q received: %q", len(wanted), wanted, lines)
+			}
+			for i := range wanted {
+				if wanted[i] != lines[i] {
+					return fmt.Errorf("checkLines: expected line @(%d): %q received: %q", i, wanted[i], lines[i])
+				}
+			}
+			return nil
+		}
+	}
+
+	testScenarios := []struct {
+		Name    string
+		Printer Printer
+		Input   string
+		Checks  []checkFunc
+	}{
+		{
+			"pass - scalar field stays on one line",
+			Printer{MaxDepth: 4, MaxArrayItems: 10},
+			`"café"`,
+			checks(checkLines(`   "field": "café"`)),
+		},
+		{
+			"pass - nested objects are indented",
+			Printer{MaxDepth: 4, MaxArrayItems: 10},
+			`{"status":500,"request":{"id":"abc","empty":{}}}`,
+			checks(checkLines(
+				`   "field":`,
+				`      "status": 500`,
+				`      "request":`,
+				`         "id": "abc"`,
+				`         "empty": {}`,
+			)),
+		},
+		{
+			"pass - long arrays are collapsed",
+			Printer{MaxDepth: 4, MaxArrayItems: 2},
+			`[1, 2, 3, 4, 5]`,
+			checks(checkLines(
+				`   "field":`,
+				`      [0]: 1`,
+				`      [1]: 2`,
+				`      … 3 more items`,
+			)),
+		},
+		{
+			"pass - fields deeper than max depth are collapsed into one line",
+			Printer{MaxDepth: 1, MaxArrayItems: 10},
+			`{"request":{"id":"abc", "body": {"x": [1, 2]}},"tags":["a"]}`,
+			checks(checkLines(
+				`   "field":`,
+				`      "request": {"id":"abc","body":{"x":[1,2]}}`,
+				`      "tags": ["a"]`,
+			)),
+		},
+		{
+			"pass - no limits",
+			Printer{},
+			`{"a":{"b":{"c":[1,2,3]}}}`,
+			checks(checkLines(
+				`   "field":`,
+				`      "a":`,
+				`         "b":`,
+				`            "c":`,
+				`               [0]: 1`,
+				`               [1]: 2`,
+				`               [2]: 3`,
+			)),
+		},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			var b bytes.Buffer
+			tc.Printer.writeField(&b, tc.Printer.theme().style("key").Sprintf("%q", "field"), valueOf(tc.Input), treeIndent, 0)
+
+			for _, check := range tc.Checks {
+				if errCheck := check(b.String()); errCheck != nil {
+					t.Error(errCheck)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package prettierzap
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// TruncatedKey is the key of the field added to records cut by the input reader,
+// holding the number of bytes dropped from them.
+const TruncatedKey = "truncated"
+
+// ParseTruncatedLine parses a line the input reader cut after dropped bytes, reading the core fields
+// with the default key mapping.
+// the fields of a JSON object written before the cut are kept, and the record is marked as truncated.
+func ParseTruncatedLine(p Parser, line []byte, dropped int) (ParsedJSON, bool) {
+	return parseTruncatedLine(p, line, dropped, defaultKeys)
+}
+
+// parseTruncatedLine parses the line like ParseTruncatedLine, reading the core fields with the key mapping.
+func parseTruncatedLine(p Parser, line []byte, dropped int, km *KeyMapping) (ParsedJSON, bool) {
+	if len(line) == 0 && dropped == 0 {
+		return nil, false
+	}
+	pj, err := parseTruncated(p, line, km)
+	if err != nil {
+		pj, _ = parseText(line, km)
+	}
+	markTruncated(pj, dropped)
+	return pj, true
+}
+
+// parseTruncated parses a truncated line with the parser, or as the beginning of a JSON object.
+func parseTruncated(p Parser, line []byte, km *KeyMapping) (ParsedJSON, error) {
+	pj, err := parseKeys(p, line, km)
+	if err == nil {
+		return pj, nil
+	}
+
+	// the scan fails at the cut, after visiting the members before it
+	lr, _ := scanLog(line, km)
+	if len(lr.keys) == 0 {
+		return nil, err
+	}
+	return lr, nil
+}
+
+// markTruncated adds the number of dropped bytes to the record, and a marker to its message.
+func markTruncated(pj ParsedJSON, dropped int) {
+	pl, ok := asParsedLog(pj)
+	if !ok || dropped == 0 {
+		return
+	}
+	pl[TruncatedKey] = strconv.Itoa(dropped)
+
+	km := mappingOf(pj)
+	key := first(km.Message, "msg")
+	for _, k := range km.Message {
+		if _, ok := pl[k]; ok {
+			key = k
+			break
+		}
+	}
+	msg := ""
+	if raw, ok := pl[key]; ok {
+		msg = valueOf(raw).String()
+	}
+	pl[key] = jsonQuote(msg + truncationMarker(dropped))
+}
+
+// truncationMarker returns the marker appended to text cut after dropped bytes.
+func truncationMarker(dropped int) string {
+	return fmt.Sprintf(" … [%d bytes truncated]", dropped)
+}
@@ -0,0 +1,57 @@
+package prettierzap
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseTruncatedLine(t *testing.T) {
+	p := keyedParser(parseAuto)
+
+	pj, ok := ParseTruncatedLine(p, []byte(`{"level":"error","msg":"too big","payload":"aaaa`), 4096)
+	if !ok {
+		t.Fatalf("ParseTruncatedLine: expected the line to be parsed")
+	}
+	if levelOf(pj) != "error" || msgOf(pj) != "too big … [4096 bytes truncated]" {
+		t.Errorf("checkSalvage: expected the fields before the cut received: %v %v", levelOf(pj), msgOf(pj))
+	}
+	if n, _ := pj.GetNumber(TruncatedKey); n != 4096 {
+		t.Errorf("checkTruncated: expected the dropped bytes received: %v", n)
+	}
+	if _, ok := pj.GetValue("payload"); ok {
+		t.Errorf("checkSalvage: expected the cut field to be dropped")
+	}
+
+	km := DefaultKeyMapping().Override(KeyMapping{Message: []string{"text"}, Level: []string{"severity"}})
+	pj, _ = parseTruncatedLine(p, []byte(`{"severity":"info","text":"hello world","payload":"aaaa`), 482, &km)
+	if msg := msgOf(pj); msg != "hello world … [482 bytes truncated]" {
+		t.Errorf("checkMessageKey: expected the marker on the message of the mapped key received: %v", msg)
+	}
+
+	pj, _ = ParseTruncatedLine(p, []byte(`plain text`), 10)
+	if !strings.HasSuffix(msgOf(pj), "[10 bytes truncated]") {
+		t.Errorf("checkText: expected the marker on the message received: %v", msgOf(pj))
+	}
+
+	pj, _ = ParseTruncatedLine(p, []byte(`{"level":"info","msg":"whole"}`), 0)
+	if _, ok := pj.GetValue(TruncatedKey); ok {
+		t.Errorf("checkWhole: expected a whole line not to be marked")
+	}
+}
+
+func TestAssemblerTruncated(t *testing.T) {
+	a := NewAssembler(keyedParser(parseAuto))
+	a.AddTruncated([]byte(`{"level":"info","msg":"big","data":"xx`), 100)
+	a.AddTruncated([]byte(`  continued`), 5)
+
+	pj, ok := a.Flush()
+	if !ok {
+		t.Fatalf("Flush: expected the record")
+	}
+	if msgOf(pj) != "big … [100 bytes truncated]" {
+		t.Errorf("checkMsg: expected the marked message received: %v", msgOf(pj))
+	}
+	if st, _ := pj.GetString("stacktrace"); st != "  continued … [5 bytes truncated]" {
+		t.Errorf("checkLines: expected the marked continuation received: %v", st)
+	}
+}
@@ -0,0 +1,305 @@
+package prettierzap
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Kind represents the type of a JSON value.
+type Kind int
+
+// kinds of JSON values
+const (
+	InvalidKind Kind = iota
+	NullKind
+	BoolKind
+	NumberKind
+	StringKind
+	ObjectKind
+	ArrayKind
+)
+
+// String returns the name of the kind.
+func (k Kind) String() string {
+	switch k {
+	case NullKind:
+		return "null"
+	case BoolKind:
+		return "bool"
+	case NumberKind:
+		return "number"
+	case StringKind:
+		return "string"
+	case ObjectKind:
+		return "object"
+	case ArrayKind:
+		return "array"
+	}
+	return "invalid"
+}
+
+// Member represents a key-value pair of a JSON object.
+type Member struct {
+	Key   string
+	Value Value
+}
+
+// Value represents a decoded JSON value.
+// the raw text of the value is kept next to the decoded data.
+type Value struct {
+	kind    Kind
+	raw     string
+	str     string
+	num     float64
+	boolean bool
+	members []Member
+	elems   []Value
+}
+
+// Kind returns the kind of the value.
+func (v Value) Kind() Kind {
+	return v.kind
+}
+
+// Raw returns the original JSON text of the value.
+func (v Value) Raw() string {
+	return v.raw
+}
+
+// AsString returns the decoded string if the value is a string.
+func (v Value) AsString() (string, bool) {
+	return v.str, v.kind == StringKind
+}
+
+// AsNumber returns the number if the value is a number.
+func (v Value) AsNumber() (float64, bool) {
+	return v.num, v.kind == NumberKind
+}
+
+// AsBool returns the boolean if the value is a boolean.
+func (v Value) AsBool() (bool, bool) {
+	return v.boolean, v.kind == BoolKind
+}
+
+// IsNull reports whether the value is a JSON null.
+func (v Value) IsNull() bool {
+	return v.kind == NullKind
+}
+
+// AsObject returns the members of the value, in their original order, if the value is an object.
+func (v Value) AsObject() ([]Member, bool) {
+	return v.members, v.kind == ObjectKind
+}
+
+// AsArray returns the elements of the value if the value is an array.
+func (v Value) AsArray() ([]Value, bool) {
+	return v.elems, v.kind == ArrayKind
+}
+
+// Get returns the member of an object value with the given key.
+func (v Value) Get(key string) (Value, bool) {
+	for _, m := range v.members {
+		if m.Key == key {
+			return m.Value, true
+		}
+	}
+	return Value{}, false
+}
+
+// Interface returns the value as a plain Go value:
+// nil, bool, float64, string, []interface{} or map[string]interface{}.
+func (v Value) Interface() interface{} {
+	switch v.kind {
+	case BoolKind:
+		return v.boolean
+	case NumberKind:
+		return v.num
+	case StringKind:
+		return v.str
+	case ObjectKind:
+		m := make(map[string]interface{}, len(v.members))
+		for _, member := range v.members {
+			m[member.Key] = member.Value.Interface()
+		}
+		return m
+	case ArrayKind:
+		a := make([]interface{}, len(v.elems))
+		for i, e := range v.elems {
+			a[i] = e.Interface()
+		}
+		return a
+	}
+	return nil
+}
+
+// String returns the text of the value for display.
+// strings are returned decoded and without quotes, the other kinds as their JSON text.
+func (v Value) String() string {
+	if v.kind == StringKind {
+		return v.str
+	}
+	return v.raw
+}
+
+// Equal reports whether two values are equal.
+// numbers are compared numerically, strings after decoding their escape sequences
+// and objects regardless of the order of their members.
+func (v Value) Equal(o Value) bool {
+	if v.kind != o.kind {
+		return false
+	}
+
+	switch v.kind {
+	case NullKind:
+		return true
+	case BoolKind:
+		return v.boolean == o.boolean
+	case NumberKind:
+		return v.num == o.num
+	case StringKind:
+		return v.str == o.str
+	case ObjectKind:
+		if len(v.members) != len(o.members) {
+			return false
+		}
+		for _, m := range v.members {
+			om, ok := o.Get(m.Key)
+			if !ok || !m.Value.Equal(om) {
+				return false
+			}
+		}
+		return true
+	case ArrayKind:
+		if len(v.elems) != len(o.elems) {
+			return false
+		}
+		for i := range v.elems {
+			if !v.elems[i].Equal(o.elems[i]) {
+				return false
+			}
+		}
+		return true
+	}
+	return v.raw == o.raw
+}
+
+// ParseValue parses the given byte array as a single JSON value.
+func ParseValue(data []byte) (Value, error) {
+	t := tokenizer{data: data}
+
+	v, err := t.parseValue(t.next(), 1)
+	if err != nil {
+		return Value{}, err
+	}
+	if tok := t.next(); tok.kind != tokenEOF {
+		return Value{}, errTrailingData
+	}
+	return v, nil
+}
+
+// valueOf decodes the given raw JSON text.
+// text that is not valid JSON, like the message of a non-JSON line, is treated as a plain string.
+func valueOf(raw string) Value {
+	v, err := ParseValue([]byte(raw))
+	if err != nil {
+		return Value{kind: StringKind, raw: raw, str: raw}
+	}
+	return v
+}
+
+// parseValue builds the value starting with the given token.
+func (t *tokenizer) parseValue(tok token, depth int) (Value, error) {
+	raw := string(t.data[tok.start:tok.end])
+
+	switch tok.kind {
+	case tokenNull:
+		return Value{kind: NullKind, raw: raw}, nil
+	case tokenTrue, tokenFalse:
+		return Value{kind: BoolKind, raw: raw, boolean: tok.kind == tokenTrue}, nil
+	case tokenNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil && !isRangeError(err) {
+			return Value{}, err
+		}
+		return Value{kind: NumberKind, raw: raw, num: n}, nil
+	case tokenString:
+		s, ok := unquote(t.data[tok.start:tok.end])
+		if !ok {
+			return Value{}, errUnexpectedToken
+		}
+		return Value{kind: StringKind, raw: raw, str: s}, nil
+	case tokenBeginObject, tokenBeginArray:
+		if depth > maxNestingDepth {
+			return Value{}, errTooDeep
+		}
+		return t.parseContainer(tok, depth)
+	}
+	return Value{}, tokenError(tok)
+}
+
+// parseContainer builds an object or an array whose opening token is the given token.
+func (t *tokenizer) parseContainer(open token, depth int) (Value, error) {
+	v := Value{kind: ArrayKind}
+	closing := tokenEndArray
+	if open.kind == tokenBeginObject {
+		v.kind = ObjectKind
+		closing = tokenEndObject
+	}
+
+	tok := t.next()
+	for tok.kind != closing {
+		var key string
+		if v.kind == ObjectKind {
+			if tok.kind != tokenString {
+				return Value{}, tokenError(tok)
+			}
+			k, ok := unquote(t.data[tok.start:tok.end])
+			if !ok {
+				return Value{}, errUnexpectedToken
+			}
+			key = k
+			if tok = t.next(); tok.kind != tokenColon {
+				return Value{}, tokenError(tok)
+			}
+			tok = t.next()
+		}
+
+		e, err := t.parseValue(tok, depth+1)
+		if err != nil {
+			return Value{}, err
+		}
+		if v.kind == ObjectKind {
+			v.members = append(v.members, Member{Key: key, Value: e})
+		} else {
+			v.elems = append(v.elems, e)
+		}
+
+		switch tok = t.next(); tok.kind {
+		case closing:
+		case tokenComma:
+			if tok = t.next(); tok.kind == closing {
+				return Value{}, errUnexpectedToken
+			}
+		default:
+			return Value{}, tokenError(tok)
+		}
+	}
+
+	v.raw = string(t.data[open.start:tok.end])
+	return v, nil
+}
+
+// isRangeError reports whether the number was valid but out of the float64 range.
+func isRangeError(err error) bool {
+	ne, ok := err.(*strconv.NumError)
+	return ok && ne.Err == strconv.ErrRange
+}
+
+// quoteValue returns the display text of a value, keeping strings quoted
+// so that they can be told apart from numbers and literals.
+func quoteValue(v Value) string {
+	if v.kind == StringKind {
+		return strconv.Quote(v.str)
+	}
+	return strings.TrimSpace(v.raw)
+}
@@ -0,0 +1,157 @@
+package prettierzap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestParseValue(t *testing.T) {
+	type checkFunc func(Value, error) error
+	checks := func(fns ...checkFunc) []checkFunc { return fns }
+
+	checkError := func(wanted bool) checkFunc {
+		return func(_ Value, err error) error {
+			if wanted != (err != nil) {
+				return fmt.Errorf("checkError: expected error: %v received: %v", wanted, err)
+			}
+			return nil
+		}
+	}
+
+	checkKind := func(wanted Kind) checkFunc {
+		return func(v Value, _ error) error {
+			if wanted != v.Kind() {
+				return fmt.Errorf("checkKind: expected kind: %v received: %v", wanted, v.Kind())
+			}
+			return nil
+		}
+	}
+
+	checkString := func(wanted string) checkFunc {
+		return func(v Value, _ error) error {
+			if s, ok := v.AsString(); !ok || s != wanted {
+				return fmt.Errorf("checkString: expected string: %q received: %q(%v)", wanted, s, ok)
+			}
+			return nil
+		}
+	}
+
+	checkNumber := func(wanted float64) checkFunc {
+		return func(v Value, _ error) error {
+			if n, ok := v.AsNumber(); !ok || n != wanted {
+				return fmt.Errorf("checkNumber: expected number: %v received: %v(%v)", wanted, n, ok)
+			}
+			return nil
+		}
+	}
+
+	checkMember := func(key string, wanted string) checkFunc {
+		return func(v Value, _ error) error {
+			m, ok := v.Get(key)
+			if !ok || m.Raw() != wanted {
+				return fmt.Errorf("checkMember: expected %q: %v received: %v(%v)", key, wanted, m.Raw(), ok)
+			}
+			return nil
+		}
+	}
+
+	checkOrder := func(wanted ...string) checkFunc {
+		return func(v Value, _ error) error {
+			members, _ := v.AsObject()
+			if len(members) != len(wanted) {
+				return fmt.Errorf("checkOrder: expected keys: %v received: %v", wanted, members)
+			}
+			for i, m := range members {
+				if m.Key != wanted[i] {
+					return fmt.Errorf("checkOrder: expected key @(%d): %v received: %v", i, wanted[i], m.Key)
+				}
+			}
+			return nil
+		}
+	}
+
+	testScenarios := []struct {
+		Name   string
+		Input  string
+		Checks []checkFunc
+	}{
+		{"pass - null", ` null `, checks(checkError(false), checkKind(NullKind))},
+		{"pass - bool", `true`, checks(checkError(false), checkKind(BoolKind))},
+		{"pass - number", `1522426145.1872783`, checks(checkError(false), checkNumber(1522426145.1872783))},
+		{"pass - unicode string", `"caf\u00e9 \"ok\""`, checks(checkError(false), checkString(`café "ok"`))},
+		{
+			"pass - nested object",
+			`{"status":500,"request":{"id":"abc"},"tags":["a","b"]}`,
+			checks(
+				checkError(false),
+				checkKind(ObjectKind),
+				checkOrder("status", "request", "tags"),
+				checkMember("request", `{"id":"abc"}`),
+				checkMember("tags", `["a","b"]`),
+			),
+		},
+		{"fails - trailing comma", `[1,]`, checks(checkError(true))},
+		{"fails - trailing data", `{} {}`, checks(checkError(true))},
+		{"fails - empty", ``, checks(checkError(true))},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			v, err := ParseValue([]byte(tc.Input))
+			for _, check := range tc.Checks {
+				if errCheck := check(v, err); errCheck != nil {
+					t.Error(errCheck)
+				}
+			}
+		})
+	}
+}
+
+func TestValueEqual(t *testing.T) {
+	testScenarios := []struct {
+		Name   string
+		A      string
+		B      string
+		Wanted bool
+	}{
+		{"pass - numbers compare numerically", `1234`, `1234.0`, true},
+		{"pass - strings compare decoded", `"caf\u00e9"`, `"café"`, true},
+		{"pass - objects ignore member order", `{"a":1,"b":[true]}`, `{"b":[true],"a":1.0}`, true},
+		{"pass - kinds differ", `"1234"`, `1234`, false},
+		{"pass - arrays compare in order", `[1,2]`, `[2,1]`, false},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			if eq := valueOf(tc.A).Equal(valueOf(tc.B)); eq != tc.Wanted {
+				t.Errorf("Equal: expected %v received: %v", tc.Wanted, eq)
+			}
+		})
+	}
+}
+
+func TestParsedLogTypedGetters(t *testing.T) {
+	pj, _ := ParseJSONByteArray([]byte(`{"level":"info","msg":"hi","port":4222,"ok":false,"none":null,"http":{"status":200},"tags":["a"]}`))
+
+	if s, ok := pj.GetString("msg"); !ok || s != "hi" {
+		t.Errorf("GetString: expected hi received: %q(%v)", s, ok)
+	}
+	if n, ok := pj.GetNumber("port"); !ok || n != 4222 {
+		t.Errorf("GetNumber: expected 4222 received: %v(%v)", n, ok)
+	}
+	if b, ok := pj.GetBool("ok"); !ok || b {
+		t.Errorf("GetBool: expected false received: %v(%v)", b, ok)
+	}
+	if !pj.IsNull("none") || pj.IsNull("missing") {
+		t.Errorf("IsNull: expected only none to be null")
+	}
+	if m, ok := pj.GetObject("http"); !ok || len(m) != 1 || m[0].Key != "status" {
+		t.Errorf("GetObject: expected status member received: %v(%v)", m, ok)
+	}
+	if a, ok := pj.GetArray("tags"); !ok || len(a) != 1 || a[0].String() != "a" {
+		t.Errorf("GetArray: expected [a] received: %v(%v)", a, ok)
+	}
+	if _, ok := pj.GetString("port"); ok {
+		t.Errorf("GetString: expected a number not to be returned as a string")
+	}
+}
@@ -0,0 +1,243 @@
+package query
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// operandKind represents the type of a literal in a query.
+type operandKind int
+
+const (
+	operandString operandKind = iota
+	operandNumber
+	operandBool
+	operandNull
+)
+
+// operand represents a literal on the right side of a comparison.
+type operand struct {
+	kind    operandKind
+	text    string
+	num     float64
+	boolean bool
+}
+
+// node represents a node of the expression tree.
+type node interface {
+	eval(r Record) bool
+}
+
+type orNode struct{ left, right node }
+
+func (n orNode) eval(r Record) bool { return n.left.eval(r) || n.right.eval(r) }
+
+type andNode struct{ left, right node }
+
+func (n andNode) eval(r Record) bool { return n.left.eval(r) && n.right.eval(r) }
+
+type notNode struct{ n node }
+
+func (n notNode) eval(r Record) bool { return !n.n.eval(r) }
+
+// existsNode matches records where the field is present and truthy.
+type existsNode struct {
+	field string
+}
+
+func (n existsNode) eval(r Record) bool {
+	v, ok := r.Field(n.field)
+	if !ok {
+		return false
+	}
+	switch t := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return t
+	case float64:
+		return t != 0
+	case string:
+		return t != ""
+	}
+	return true
+}
+
+// compareNode compares a field with a literal.
+// comparisons against a missing field are false, except for `!=`.
+type compareNode struct {
+	field   string
+	op      tokenKind
+	operand operand
+}
+
+func (n compareNode) eval(r Record) bool {
+	v, ok := r.Field(n.field)
+	if !ok {
+		return n.op == tokenNeq
+	}
+
+	switch n.op {
+	case tokenEq:
+		return equal(v, n.operand)
+	case tokenNeq:
+		return !equal(v, n.operand)
+	case tokenContains:
+		return contains(v, n.operand)
+	}
+
+	c, ok := compare(v, n.operand)
+	if !ok {
+		return false
+	}
+	switch n.op {
+	case tokenLt:
+		return c < 0
+	case tokenLe:
+		return c <= 0
+	case tokenGt:
+		return c > 0
+	case tokenGe:
+		return c >= 0
+	}
+	return false
+}
+
+// matchNode matches the text of a field against a regular expression.
+type matchNode struct {
+	field  string
+	re     *regexp.Regexp
+	negate bool
+}
+
+func (n matchNode) eval(r Record) bool {
+	v, ok := r.Field(n.field)
+	if !ok {
+		return n.negate
+	}
+	return n.re.MatchString(text(v)) != n.negate
+}
+
+// inNode matches records where the field equals one of the literals.
+type inNode struct {
+	field string
+	set   []operand
+}
+
+func (n inNode) eval(r Record) bool {
+	v, ok := r.Field(n.field)
+	if !ok {
+		return false
+	}
+	for _, o := range n.set {
+		if equal(v, o) {
+			return true
+		}
+	}
+	return false
+}
+
+// equal reports whether a field value equals a literal.
+// numbers are compared numerically, also when the field holds a number as a string.
+func equal(v interface{}, o operand) bool {
+	switch o.kind {
+	case operandNull:
+		return v == nil
+	case operandBool:
+		b, ok := v.(bool)
+		return ok && b == o.boolean
+	case operandNumber:
+		n, ok := number(v)
+		return ok && n == o.num
+	}
+
+	switch t := v.(type) {
+	case string:
+		return t == o.text
+	case float64:
+		n, err := strconv.ParseFloat(o.text, 64)
+		return err == nil && n == t
+	case bool:
+		return strconv.FormatBool(t) == o.text
+	}
+	return false
+}
+
+// compare orders a field value against a literal, numerically when both sides are numbers
+// and by text otherwise.
+func compare(v interface{}, o operand) (int, bool) {
+	if v == nil || o.kind == operandNull || o.kind == operandBool {
+		return 0, false
+	}
+
+	if n, ok := number(v); ok {
+		on := o.num
+		if o.kind == operandString {
+			var err error
+			if on, err = strconv.ParseFloat(o.text, 64); err != nil {
+				return strings.Compare(text(v), o.text), true
+			}
+		}
+		switch {
+		case n < on:
+			return -1, true
+		case n > on:
+			return 1, true
+		}
+		return 0, true
+	}
+
+	s, ok := v.(string)
+	if !ok {
+		return 0, false
+	}
+	return strings.Compare(s, o.text), true
+}
+
+// contains reports whether a string field contains the literal as a substring
+// or an array field contains an element equal to the literal.
+func contains(v interface{}, o operand) bool {
+	switch t := v.(type) {
+	case string:
+		return strings.Contains(t, o.text)
+	case []interface{}:
+		for _, e := range t {
+			if equal(e, o) {
+				return true
+			}
+		}
+		return false
+	case map[string]interface{}:
+		_, ok := t[o.text]
+		return ok
+	}
+	return false
+}
+
+// number returns the numeric value of a field, parsing strings holding numbers.
+func number(v interface{}) (float64, bool) {
+	switch t := v.(type) {
+	case float64:
+		return t, true
+	case string:
+		n, err := strconv.ParseFloat(t, 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// text returns the text of a field value used by regular expressions.
+func text(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(t)
+	}
+	return ""
+}
@@ -0,0 +1,290 @@
+package query
+
+import (
+	"strings"
+)
+
+// tokenKind represents the kind of a query token.
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenField
+	tokenString
+	tokenNumber
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenAnd
+	tokenOr
+	tokenNot
+	tokenEq
+	tokenNeq
+	tokenLt
+	tokenLe
+	tokenGt
+	tokenGe
+	tokenMatch
+	tokenNotMatch
+	tokenIn
+	tokenContains
+	tokenTrue
+	tokenFalse
+	tokenNull
+)
+
+// token represents a lexed token of a query.
+type token struct {
+	kind tokenKind
+	text string // decoded text of strings, raw text otherwise
+	pos  int    // byte offset of the token in the query
+}
+
+// keywords are the bare words with a special meaning in a query.
+var keywords = map[string]tokenKind{
+	"and":      tokenAnd,
+	"or":       tokenOr,
+	"not":      tokenNot,
+	"in":       tokenIn,
+	"contains": tokenContains,
+	"true":     tokenTrue,
+	"false":    tokenFalse,
+	"null":     tokenNull,
+}
+
+// describe returns a human readable description of the token for error messages.
+func (t token) describe() string {
+	switch t.kind {
+	case tokenEOF:
+		return "end of query"
+	case tokenString:
+		return "string " + quote(t.text)
+	case tokenNumber:
+		return "number " + t.text
+	case tokenField:
+		return "field " + t.text
+	}
+	return quote(t.text)
+}
+
+// lexer splits a query into tokens.
+type lexer struct {
+	src string
+	pos int
+}
+
+// lex returns all tokens of the query, ending with an EOF token.
+func lex(src string) ([]token, error) {
+	l := lexer{src: src}
+	tokens := make([]token, 0)
+	for {
+		t, err := l.next()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.kind == tokenEOF {
+			return tokens, nil
+		}
+	}
+}
+
+// next returns the next token of the query.
+func (l *lexer) next() (token, error) {
+	for l.pos < len(l.src) && isSpace(l.src[l.pos]) {
+		l.pos++
+	}
+	if l.pos >= len(l.src) {
+		return token{kind: tokenEOF, pos: l.pos}, nil
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	two := ""
+	if l.pos+1 < len(l.src) {
+		two = l.src[l.pos : l.pos+2]
+	}
+
+	switch two {
+	case "&&":
+		return l.emit(tokenAnd, start, 2), nil
+	case "||":
+		return l.emit(tokenOr, start, 2), nil
+	case "==":
+		return l.emit(tokenEq, start, 2), nil
+	case "!=":
+		return l.emit(tokenNeq, start, 2), nil
+	case "<=":
+		return l.emit(tokenLe, start, 2), nil
+	case ">=":
+		return l.emit(tokenGe, start, 2), nil
+	case "!~":
+		return l.emit(tokenNotMatch, start, 2), nil
+	case "=~":
+		return l.emit(tokenMatch, start, 2), nil
+	}
+
+	switch c {
+	case '(':
+		return l.emit(tokenLParen, start, 1), nil
+	case ')':
+		return l.emit(tokenRParen, start, 1), nil
+	case ',':
+		return l.emit(tokenComma, start, 1), nil
+	case '=':
+		return l.emit(tokenEq, start, 1), nil
+	case '<':
+		return l.emit(tokenLt, start, 1), nil
+	case '>':
+		return l.emit(tokenGt, start, 1), nil
+	case '~':
+		return l.emit(tokenMatch, start, 1), nil
+	case '!':
+		return l.emit(tokenNot, start, 1), nil
+	case '"', '\'':
+		return l.lexString(c)
+	}
+
+	if c == '-' || isDigit(c) {
+		if t, ok := l.lexNumber(); ok {
+			return t, nil
+		}
+	}
+	if isFieldStart(c) {
+		return l.lexWord()
+	}
+	return token{}, errorAt(l.src, start, "unexpected character %s", quote(string(c)))
+}
+
+// emit returns a token of the given length starting at start.
+func (l *lexer) emit(kind tokenKind, start, length int) token {
+	l.pos = start + length
+	return token{kind: kind, text: l.src[start:l.pos], pos: start}
+}
+
+// lexString lexes a string quoted with either single or double quotes.
+func (l *lexer) lexString(q byte) (token, error) {
+	start := l.pos
+	var b strings.Builder
+
+	i := start + 1
+	for i < len(l.src) {
+		c := l.src[i]
+		switch {
+		case c == q:
+			l.pos = i + 1
+			return token{kind: tokenString, text: b.String(), pos: start}, nil
+		case c == '\\' && i+1 < len(l.src):
+			switch e := l.src[i+1]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			default:
+				// keep regex escapes like \d and \. intact
+				if e != q && e != '\\' {
+					b.WriteByte('\\')
+				}
+				b.WriteByte(e)
+			}
+			i += 2
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return token{}, errorAt(l.src, start, "unterminated string")
+}
+
+// lexNumber lexes a decimal number with an optional fraction and exponent.
+func (l *lexer) lexNumber() (token, bool) {
+	start := l.pos
+	i := start
+	if l.src[i] == '-' {
+		i++
+	}
+	digits := i
+	for i < len(l.src) && (isDigit(l.src[i]) || l.src[i] == '.') {
+		i++
+	}
+	if i == digits {
+		return token{}, false
+	}
+	if i < len(l.src) && (l.src[i] == 'e' || l.src[i] == 'E') {
+		j := i + 1
+		if j < len(l.src) && (l.src[j] == '+' || l.src[j] == '-') {
+			j++
+		}
+		if j < len(l.src) && isDigit(l.src[j]) {
+			for j < len(l.src) && isDigit(l.src[j]) {
+				j++
+			}
+			i = j
+		}
+	}
+	// a number directly followed by letters is a bare word, like `5xx`
+	if i < len(l.src) && isFieldChar(l.src[i]) {
+		return token{}, false
+	}
+	l.pos = i
+	return token{kind: tokenNumber, text: l.src[start:i], pos: start}, true
+}
+
+// lexWord lexes a keyword or a field path like `http.request.id`, `tags[0]` or `headers["x.y"]`.
+func (l *lexer) lexWord() (token, error) {
+	start := l.pos
+	i := start
+	for i < len(l.src) {
+		c := l.src[i]
+		if isFieldChar(c) {
+			i++
+			continue
+		}
+		if c != '[' {
+			break
+		}
+
+		// bracketed segment, possibly holding a quoted key
+		j := i + 1
+		if j < len(l.src) && l.src[j] == '"' {
+			j++
+			for j < len(l.src) && l.src[j] != '"' {
+				if l.src[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			j++
+		}
+		for j < len(l.src) && l.src[j] != ']' {
+			j++
+		}
+		if j >= len(l.src) {
+			return token{}, errorAt(l.src, i, "unterminated %s in field path", quote("["))
+		}
+		i = j + 1
+	}
+
+	l.pos = i
+	word := l.src[start:i]
+	if kind, ok := keywords[strings.ToLower(word)]; ok {
+		return token{kind: kind, text: word, pos: start}, nil
+	}
+	return token{kind: tokenField, text: word, pos: start}, nil
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isFieldStart(c byte) bool {
+	return c == '_' || c == '@' || c == '$' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || isDigit(c) || c >= 0x80
+}
+
+func isFieldChar(c byte) bool {
+	return isFieldStart(c) || c == '.' || c == '-' || c == ':' || c == '/'
+}
@@ -0,0 +1,190 @@
+package query
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// parser builds the expression tree of a query from its tokens.
+//
+//	expr       = and { ("||" | "or") and }
+//	and        = unary { ("&&" | "and") unary }
+//	unary      = ("!" | "not") unary | "(" expr ")" | comparison
+//	comparison = field [ op operand | "in" "(" operand { "," operand } ")" ]
+type parser struct {
+	src    string
+	tokens []token
+	pos    int
+}
+
+// parse parses the whole query.
+func (p *parser) parse() (node, error) {
+	if p.peek().kind == tokenEOF {
+		return nil, errorAt(p.src, 0, "empty query")
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokenEOF {
+		return nil, p.unexpected(t, "an operator like && or ||")
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// unexpected returns the error for a token that doesn't fit where it was found.
+func (p *parser) unexpected(t token, expected string) error {
+	return errorAt(p.src, t.pos, "unexpected %s, expected %s", t.describe(), expected)
+}
+
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenOr {
+		p.advance()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = orNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokenAnd {
+		p.advance()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = andNode{left, right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (node, error) {
+	switch t := p.peek(); t.kind {
+	case tokenNot:
+		p.advance()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{n}, nil
+	case tokenLParen:
+		p.advance()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.peek(); c.kind != tokenRParen {
+			return nil, p.unexpected(c, `")"`)
+		}
+		p.advance()
+		return n, nil
+	case tokenField:
+		return p.parseComparison()
+	default:
+		return nil, p.unexpected(t, "a field name, \"!\" or \"(\"")
+	}
+}
+
+// parseComparison parses a comparison, or a bare field that checks for truthiness.
+func (p *parser) parseComparison() (node, error) {
+	field := p.advance()
+
+	switch op := p.peek(); op.kind {
+	case tokenEq, tokenNeq, tokenLt, tokenLe, tokenGt, tokenGe, tokenContains:
+		p.advance()
+		v, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return compareNode{field: field.text, op: op.kind, operand: v}, nil
+	case tokenMatch, tokenNotMatch:
+		p.advance()
+		t := p.peek()
+		v, err := p.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		re, errRe := regexp.Compile(v.text)
+		if errRe != nil {
+			return nil, errorAt(p.src, t.pos, "invalid regular expression: %v", errRe)
+		}
+		return matchNode{field: field.text, re: re, negate: op.kind == tokenNotMatch}, nil
+	case tokenIn:
+		p.advance()
+		if t := p.peek(); t.kind != tokenLParen {
+			return nil, p.unexpected(t, `"(" after in`)
+		}
+		p.advance()
+
+		set := make([]operand, 0)
+		for {
+			v, err := p.parseOperand()
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, v)
+
+			t := p.advance()
+			if t.kind == tokenRParen {
+				break
+			}
+			if t.kind != tokenComma {
+				return nil, p.unexpected(t, `"," or ")"`)
+			}
+		}
+		return inNode{field: field.text, set: set}, nil
+	}
+	return existsNode{field: field.text}, nil
+}
+
+// parseOperand parses the right side of a comparison.
+func (p *parser) parseOperand() (operand, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokenString:
+		p.advance()
+		return operand{kind: operandString, text: t.text}, nil
+	case tokenField:
+		// bare words like `error` are strings
+		p.advance()
+		return operand{kind: operandString, text: t.text}, nil
+	case tokenNumber:
+		p.advance()
+		n, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return operand{}, errorAt(p.src, t.pos, "invalid number %s", t.text)
+		}
+		return operand{kind: operandNumber, text: t.text, num: n}, nil
+	case tokenTrue, tokenFalse:
+		p.advance()
+		return operand{kind: operandBool, text: t.text, boolean: t.kind == tokenTrue}, nil
+	case tokenNull:
+		p.advance()
+		return operand{kind: operandNull, text: t.text}, nil
+	}
+	return operand{}, p.unexpected(t, "a value")
+}
@@ -0,0 +1,84 @@
+// Package query implements the expression language used to filter logs, like:
+//
+//	level in (error, warn) && http.status >= 500 && !(caller ~ "healthcheck") || msg contains "timeout"
+//
+// Operands on the left side of an operator are field paths, operands on the right side are
+// strings (quoted or bare words), numbers, `true`, `false` or `null`. Supported operators are
+// `==`, `!=`, `<`, `<=`, `>`, `>=`, `~` (regex match), `!~`, `in (...)` and `contains`,
+// combined with `&&`/`and`, `||`/`or`, `!`/`not` and parentheses. A field path on its own
+// matches records where the field is present and truthy.
+package query
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Record represents a log record that queries are evaluated against.
+type Record interface {
+	// Field returns the value at the given field path as nil, bool, float64, string,
+	// []interface{} or map[string]interface{}
+	Field(path string) (interface{}, bool)
+}
+
+// Query represents a parsed query expression.
+type Query struct {
+	src  string
+	root node
+}
+
+// Parse parses the given query expression.
+func Parse(src string) (*Query, error) {
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := parser{src: src, tokens: tokens}
+	root, err := p.parse()
+	if err != nil {
+		return nil, err
+	}
+	return &Query{src: src, root: root}, nil
+}
+
+// Match reports whether the record satisfies the query.
+func (q *Query) Match(r Record) bool {
+	return q.root.eval(r)
+}
+
+// String returns the source of the query.
+func (q *Query) String() string {
+	return q.src
+}
+
+// SyntaxError represents an error in the syntax of a query.
+type SyntaxError struct {
+	Query  string // the query with the error
+	Column int    // column of the offending token, starting from 1
+	Msg    string // description of the error
+}
+
+// Error returns the description of the error along with its column.
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query: syntax error at column %d: %s", e.Column, e.Msg)
+}
+
+// Pointer returns the query followed by a line pointing at the offending column.
+func (e *SyntaxError) Pointer() string {
+	return fmt.Sprintf("%s\n%s^", e.Query, strings.Repeat(" ", e.Column-1))
+}
+
+// errorAt returns a syntax error at the given byte offset of the query.
+func errorAt(src string, pos int, format string, args ...interface{}) error {
+	return &SyntaxError{
+		Query:  src,
+		Column: len([]rune(src[:pos])) + 1,
+		Msg:    fmt.Sprintf(format, args...),
+	}
+}
+
+func quote(s string) string {
+	return strconv.Quote(s)
+}
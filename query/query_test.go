@@ -0,0 +1,129 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// mapRecord is a record backed by a map of field paths.
+type mapRecord map[string]interface{}
+
+func (m mapRecord) Field(path string) (interface{}, bool) {
+	v, ok := m[path]
+	return v, ok
+}
+
+func TestParse(t *testing.T) {
+	type checkFunc func(*Query, error) error
+	checks := func(fns ...checkFunc) []checkFunc { return fns }
+
+	checkNoError := func() checkFunc {
+		return func(_ *Query, err error) error {
+			if err != nil {
+				return fmt.Errorf("checkNoError: expected no error received: %v", err)
+			}
+			return nil
+		}
+	}
+
+	checkSyntaxError := func(column int, msg string) checkFunc {
+		return func(_ *Query, err error) error {
+			se, ok := err.(*SyntaxError)
+			if !ok {
+				return fmt.Errorf("checkSyntaxError: expected syntax error received: %v", err)
+			}
+			if se.Column != column || !strings.Contains(se.Msg, msg) {
+				return fmt.Errorf("checkSyntaxError: expected column %d with %q received: column %d with %q", column, msg, se.Column, se.Msg)
+			}
+			return nil
+		}
+	}
+
+	testScenarios := []struct {
+		Name   string
+		Query  string
+		Checks []checkFunc
+	}{
+		{"pass - full expression", `level in (error, warn) && http.status >= 500 && !(caller ~ "healthcheck") || msg contains "timeout"`, checks(checkNoError())},
+		{"pass - keywords", `not level = debug and (ok or tags[0] != 'x')`, checks(checkNoError())},
+		{"pass - bare field", `error`, checks(checkNoError())},
+		{"fails - empty query", `  `, checks(checkSyntaxError(1, "empty query"))},
+		{"fails - missing value", `level == && ok`, checks(checkSyntaxError(10, `unexpected "&&", expected a value`))},
+		{"fails - missing closing paren", `(level == error`, checks(checkSyntaxError(16, `expected ")"`))},
+		{"fails - unterminated string", `msg contains "time`, checks(checkSyntaxError(14, "unterminated string"))},
+		{"fails - invalid regex", `caller ~ "a(b"`, checks(checkSyntaxError(10, "invalid regular expression"))},
+		{"fails - unexpected character", `level # error`, checks(checkSyntaxError(7, `unexpected character "#"`))},
+		{"fails - missing operator", `level error`, checks(checkSyntaxError(7, "expected an operator"))},
+		{"fails - bad in list", `level in (error warn)`, checks(checkSyntaxError(17, `expected "," or ")"`))},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			q, err := Parse(tc.Query)
+			for _, check := range tc.Checks {
+				if errCheck := check(q, err); errCheck != nil {
+					t.Error(errCheck)
+				}
+			}
+		})
+	}
+}
+
+func TestSyntaxErrorPointer(t *testing.T) {
+	_, err := Parse(`level == && ok`)
+	se, ok := err.(*SyntaxError)
+	if !ok {
+		t.Fatalf("expected syntax error received: %v", err)
+	}
+
+	wanted := "level == && ok\n         ^"
+	if se.Pointer() != wanted {
+		t.Errorf("Pointer: expected %q received: %q", wanted, se.Pointer())
+	}
+}
+
+func TestMatch(t *testing.T) {
+	records := []mapRecord{
+		{"level": "error", "caller": "api/handler.go:10", "msg": "request failed", "http": map[string]interface{}{}, "http.status": float64(503), "tags": []interface{}{"x", "y"}},
+		{"level": "warn", "caller": "api/healthcheck.go:5", "msg": "slow", "http.status": float64(500)},
+		{"level": "info", "caller": "db/conn.go:1", "msg": "connection timeout", "retry": true},
+		{"level": "info", "caller": "db/conn.go:1", "msg": "connected", "http.status": "404", "user": nil},
+	}
+
+	testScenarios := []struct {
+		Name   string
+		Query  string
+		Wanted []bool
+	}{
+		{
+			"pass - full expression",
+			`level in (error, warn) && http.status >= 500 && !(caller ~ "healthcheck") || msg contains "timeout"`,
+			[]bool{true, false, true, false},
+		},
+		{"pass - numeric comparison of string field", `http.status >= 404`, []bool{true, true, false, true}},
+		{"pass - equality of number", `http.status == 500`, []bool{false, true, false, false}},
+		{"pass - not equal matches missing fields", `level != info`, []bool{true, true, false, false}},
+		{"pass - regex", `caller ~ "^db/"`, []bool{false, false, true, true}},
+		{"pass - negated regex", `caller !~ "^db/"`, []bool{true, true, false, false}},
+		{"pass - array contains", `tags contains y`, []bool{true, false, false, false}},
+		{"pass - bool and truthiness", `retry == true || retry`, []bool{false, false, true, false}},
+		{"pass - null", `user == null`, []bool{false, false, false, true}},
+		{"pass - precedence of and over or", `level == info || level == warn && msg == slow`, []bool{false, true, true, true}},
+		{"pass - keywords are case insensitive", `NOT level == info AND msg CONTAINS "fail"`, []bool{true, false, false, false}},
+	}
+
+	for _, tc := range testScenarios {
+		t.Run(tc.Name, func(t *testing.T) {
+			q, err := Parse(tc.Query)
+			if err != nil {
+				t.Fatalf("Parse: expected no error received: %v", err)
+			}
+			for i, r := range records {
+				if got := q.Match(r); got != tc.Wanted[i] {
+					t.Errorf("Match: expected @(%d) %v received: %v", i, tc.Wanted[i], got)
+				}
+			}
+		})
+	}
+}